@@ -0,0 +1,114 @@
+package deckcodec
+
+import (
+	"context"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// BatchOpts configures DecodeBatch and EncodeBatch.
+type BatchOpts struct {
+	Workers int // number of worker goroutines; <= 0 means runtime.GOMAXPROCS(0)
+}
+
+// BatchResult is the outcome for one input of a batch. Results are returned
+// in input order, so Index always equals the result's position in the slice.
+type BatchResult[T any] struct {
+	Index int
+	Value T
+	Err   error
+}
+
+// DecodeBatch decodes codes with a bounded pool of workers sharing c.
+// Per-item failures are reported in BatchResult.Err and do not stop the batch.
+// If ctx is cancelled, no further inputs are read; the results collected so far
+// are returned together with ctx.Err().
+//
+// The input sequence runs on its own goroutine. If it can block, it must end
+// once ctx is done, or that goroutine outlives the call (see SeqFromChan).
+func DecodeBatch(ctx context.Context, c *Codec, codes iter.Seq[string], opts BatchOpts) ([]BatchResult[DeckOutput], error) {
+	return runBatch(ctx, codes, opts, c.Decode)
+}
+
+// EncodeBatch encodes decks with a bounded pool of workers sharing c.
+// It follows the same ordering, error and cancellation rules as DecodeBatch.
+func EncodeBatch(ctx context.Context, c *Codec, decks iter.Seq[DeckInput], opts BatchOpts) ([]BatchResult[string], error) {
+	return runBatch(ctx, decks, opts, c.Encode)
+}
+
+// SeqFromChan adapts a channel to an iter.Seq for the batch APIs.
+// The sequence ends when ch is closed. The sender must close ch even when the
+// batch's ctx is cancelled: until then the goroutine reading ch stays blocked.
+func SeqFromChan[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// runBatch feeds seq to opts.Workers goroutines running fn and collects the
+// results by input index.
+func runBatch[In, Out any](ctx context.Context, seq iter.Seq[In], opts BatchOpts, fn func(In) (Out, error)) ([]BatchResult[Out], error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	type job struct {
+		i  int
+		in In
+	}
+	jobs := make(chan job)
+	results := make(chan BatchResult[Out])
+
+	// Producer: the only goroutine that iterates seq. A job counts as sent only
+	// once a worker has received it, so every sent job yields exactly one result.
+	go func() {
+		defer close(jobs)
+		i := 0
+		for in := range seq {
+			select {
+			case jobs <- job{i: i, in: in}:
+				i++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case j, ok := <-jobs:
+					if !ok {
+						return
+					}
+					v, err := fn(j.in)
+					results <- BatchResult[Out]{Index: j.i, Value: v, Err: err}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var out []BatchResult[Out]
+	for r := range results {
+		for len(out) <= r.Index {
+			out = append(out, BatchResult[Out]{Index: len(out)})
+		}
+		out[r.Index] = r
+	}
+	return out, ctx.Err()
+}
@@ -0,0 +1,148 @@
+package deckcodec
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDecodeBatch_OrderAndErrors decodes many codes (some invalid) and checks
+// that results keep input order and errors stay per item.
+func TestDecodeBatch_OrderAndErrors(t *testing.T) {
+	p := benchPack(1, 300)
+	c, err := NewCodec(p)
+	if err != nil {
+		t.Fatalf("NewCodec failed: %v", err)
+	}
+	var codes []string
+	for i := range 500 {
+		if i%7 == 3 {
+			codes = append(codes, "!bad!")
+			continue
+		}
+		code, err := c.Encode(DeckInput{Leader: []uint64{p.Cards[i%300]}})
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		codes = append(codes, code)
+	}
+
+	res, err := DecodeBatch(context.Background(), c, slices.Values(codes), BatchOpts{Workers: 8})
+	if err != nil {
+		t.Fatalf("DecodeBatch failed: %v", err)
+	}
+	if len(res) != len(codes) {
+		t.Fatalf("got %d results, want %d", len(res), len(codes))
+	}
+	for i, r := range res {
+		if r.Index != i {
+			t.Fatalf("result %d has index %d", i, r.Index)
+		}
+		if i%7 == 3 {
+			if !errors.Is(r.Err, ErrInvalidCode) {
+				t.Fatalf("item %d: expected ErrInvalidCode, got %v", i, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || !slices.Equal(r.Value.Leader, []uint64{p.Cards[i%300]}) {
+			t.Fatalf("item %d: got %+v, %v", i, r.Value, r.Err)
+		}
+	}
+}
+
+// TestEncodeBatch_Chan feeds decks through a channel and compares with Codec.Encode.
+func TestEncodeBatch_Chan(t *testing.T) {
+	c, _ := NewCodec(benchPack(2, 100))
+	ch := make(chan DeckInput)
+	go func() {
+		defer close(ch)
+		for i := range 50 {
+			ch <- DeckInput{Deck: map[uint64]uint8{uint64(1000 + 7*i): uint8(1 + i%4)}}
+		}
+	}()
+	res, err := EncodeBatch(context.Background(), c, SeqFromChan(ch), BatchOpts{})
+	if err != nil {
+		t.Fatalf("EncodeBatch failed: %v", err)
+	}
+	if len(res) != 50 {
+		t.Fatalf("got %d results", len(res))
+	}
+	for i, r := range res {
+		want, _ := c.Encode(DeckInput{Deck: map[uint64]uint8{uint64(1000 + 7*i): uint8(1 + i%4)}})
+		if r.Err != nil || r.Value != want {
+			t.Fatalf("item %d: got %q, %v; want %q", i, r.Value, r.Err, want)
+		}
+	}
+}
+
+// TestDecodeBatch_Cancel cancels the context mid-stream: reading stops, the
+// results so far are gap-free and ctx.Err() is returned.
+func TestDecodeBatch_Cancel(t *testing.T) {
+	c, _ := NewCodec(benchPack(1, 10))
+	code, _ := c.Encode(DeckInput{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The producer may still be unwinding when DecodeBatch returns, so count atomically.
+	var read atomic.Int64
+	seq := func(yield func(string) bool) {
+		for ; read.Load() < 1_000_000; read.Add(1) {
+			if read.Load() == 100 {
+				cancel()
+			}
+			if !yield(code) {
+				return
+			}
+		}
+	}
+	res, err := DecodeBatch(ctx, c, seq, BatchOpts{Workers: 4})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n := read.Load(); n >= 1_000_000 || int64(len(res)) > n+1 {
+		t.Fatalf("producer did not stop: read=%d results=%d", n, len(res))
+	}
+	for i, r := range res {
+		if r.Index != i || r.Err != nil {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+	}
+}
+
+// TestSeqFromChan_Cancel follows SeqFromChan's contract: the sender stops and
+// closes the channel on cancel, and the goroutine reading it then exits.
+func TestSeqFromChan_Cancel(t *testing.T) {
+	c, _ := NewCodec(benchPack(1, 10))
+	code, _ := c.Encode(DeckInput{})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan string)
+	stopped := make(chan struct{})
+	seq := func(yield func(string) bool) {
+		defer close(stopped)
+		SeqFromChan(ch)(yield)
+	}
+	go func() {
+		defer close(ch)
+		for i := 0; ; i++ {
+			if i == 10 {
+				cancel()
+			}
+			select {
+			case ch <- code:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	if _, err := DecodeBatch(ctx, c, seq, BatchOpts{Workers: 2}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sequence still reading the channel after it was closed")
+	}
+}
@@ -0,0 +1,249 @@
+// Package bitstream reads and writes LSB-first bit streams: fixed-width fields
+// of 0..64 bits plus unary, Elias-gamma/delta, Golomb-Rice and LEB128 codes.
+// It is the bit layer of deckcodec and is stable for reuse by other formats.
+//
+// Bits are packed starting at the least significant bit of each byte, and a
+// field's low bits come first. A final partial byte is zero-padded.
+package bitstream
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// Writer is a bit-level writer that allows writing arbitrary numbers of bits into a byte buffer.
+// Bits are accumulated LSB-first in the 64-bit word 'acc'; whenever the word is full it is
+// flushed to 'Buf' as 8 little-endian bytes. The byte output is identical to flushing
+// one byte at a time.
+type Writer struct {
+	Buf   []byte // Output buffer where bytes are written as they are completed.
+	acc   uint64 // Bit accumulator; holds bits that have not yet been flushed to the buffer.
+	nbits int    // Number of bits currently stored in the accumulator (0..63).
+}
+
+// NewWriter returns a Writer whose buffer is preallocated for about sizeBits bits.
+func NewWriter(sizeBits int) Writer {
+	// Round up to whole words: flushes always append 8 bytes.
+	return Writer{Buf: make([]byte, 0, (sizeBits+63)/64*8)}
+}
+
+// Reset discards any written bits and keeps the buffer's capacity for reuse.
+func (w *Writer) Reset() {
+	w.Buf = w.Buf[:0]
+	w.acc, w.nbits = 0, 0
+}
+
+// lowMask returns a mask of the lowest 'width' bits (width in 0..64).
+// Go defines shifts by >= 64 as yielding 0, so width 0 gives an empty mask.
+func lowMask(width int) uint64 {
+	return ^uint64(0) >> (64 - uint(width))
+}
+
+// WriteBits writes the lowest 'width' bits of 'v' (width in 0..64) into the buffer.
+// Bits are accumulated in 'acc' until the 64-bit word is full, at which point
+// the word is flushed to the buffer.
+func (w *Writer) WriteBits(v uint64, width int) {
+	// Mask the input value to only keep the lowest 'width' bits.
+	v &= lowMask(width)
+	if n := w.nbits + width; n < 64 {
+		// Fits into the accumulator without filling it.
+		w.acc |= v << uint(w.nbits)
+		w.nbits = n
+		return
+	}
+	w.spill(v, width)
+}
+
+// spill fills the accumulator with the low bits of v, flushes the whole word
+// and keeps the remaining high bits of v.
+func (w *Writer) spill(v uint64, width int) {
+	free := 64 - w.nbits
+	w.acc |= v << uint(w.nbits)
+	w.Buf = binary.LittleEndian.AppendUint64(w.Buf, w.acc)
+	w.acc = v >> uint(free) // shifting by 64 (empty accumulator before) yields 0
+	w.nbits = width - free
+}
+
+// Len returns the number of bits written so far, including any bytes already in Buf.
+func (w *Writer) Len() int {
+	return len(w.Buf)*8 + w.nbits
+}
+
+// Align pads with zero bits up to the next byte boundary.
+func (w *Writer) Align() {
+	w.WriteBits(0, (8-w.nbits%8)%8)
+}
+
+// Finish flushes any remaining bits in the accumulator to the buffer.
+// A trailing partial byte holds the leftover bits in its lowest bits.
+// After flushing, the accumulator and bit count are reset to zero.
+func (w *Writer) Finish() []byte {
+	for w.nbits > 0 {
+		w.Buf = append(w.Buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+	w.acc, w.nbits = 0, 0 // Reset accumulator and bit count.
+	return w.Buf
+}
+
+// Reader reads bits from a byte slice, refilling its 64-bit accumulator a word at a time.
+// The accumulator only ever holds bits of the logical stream, so a read that
+// fits in it needs no further bounds check.
+// 'nbits' is the number of valid bits currently in the accumulator.
+// 'end' is the stream offset just past them, so end-nbits is the read offset.
+type Reader struct {
+	Src   []byte // Source byte slice to read from.
+	acc   uint64 // Bit accumulator.
+	nbits int    // Number of valid bits currently in the accumulator.
+	end   int    // Bit offset just past the accumulated bits.
+	total int    // Valid bits in the logical stream (excludes zero-padding).
+}
+
+// ErrShort is returned when there are not enough bytes left in the source to satisfy a read.
+var ErrShort = errors.New("bitstream: unexpected EOF")
+
+// ErrOverflow is returned when a variable-length code does not fit in 64 bits.
+var ErrOverflow = errors.New("bitstream: value overflows 64 bits")
+
+// Error reports a failed read together with the bit offset where it started.
+// It wraps ErrShort or ErrOverflow.
+type Error struct {
+	Op     string // e.g. "read 12 bits", "gamma"
+	Offset int    // bit offset of the failed read
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return "bitstream: " + e.Op + " at bit " + strconv.Itoa(e.Offset) + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// fail returns an *Error for an operation starting at bit offset off.
+func fail(op string, off int, err error) error {
+	return &Error{Op: op, Offset: off, Err: err}
+}
+
+// shortRead is the error for a fixed-width read that cannot be satisfied.
+func (r *Reader) shortRead(width int) error {
+	return fail("read "+strconv.Itoa(width)+" bits", r.Offset(), ErrShort)
+}
+
+// NewReader constructs a Reader with a known number of valid bits.
+// If validBits < 0, all bits in src are considered valid (len(src)*8).
+func NewReader(src []byte, validBits int) Reader {
+	if validBits < 0 {
+		validBits = len(src) * 8
+	}
+	return Reader{Src: src, total: validBits}
+}
+
+// Reset rewinds the Reader onto a new source, as NewReader(src, validBits) would.
+func (r *Reader) Reset(src []byte, validBits int) {
+	*r = NewReader(src, validBits)
+}
+
+// Offset returns the number of bits consumed so far, i.e. the bit offset of the next read.
+func (r *Reader) Offset() int {
+	return r.end - r.nbits
+}
+
+// BitsRemaining returns the number of valid bits not yet read.
+func (r *Reader) BitsRemaining() int {
+	return r.total - r.Offset()
+}
+
+// Peek returns the next 'width' bits without consuming them.
+func (r *Reader) Peek(width int) (uint64, error) {
+	c := *r
+	return c.ReadBits(width)
+}
+
+// Skip consumes n bits. On failure nothing is consumed.
+func (r *Reader) Skip(n int) error {
+	if n < 0 || n > r.BitsRemaining() {
+		return fail("skip "+strconv.Itoa(n)+" bits", r.Offset(), ErrShort)
+	}
+	for n > 0 {
+		w := min(n, 64)
+		if _, err := r.ReadBits(w); err != nil {
+			return err
+		}
+		n -= w
+	}
+	return nil
+}
+
+// Align skips to the next byte boundary (a no-op if already aligned).
+func (r *Reader) Align() error {
+	return r.Skip((8 - r.Offset()%8) % 8)
+}
+
+// refill reloads the accumulator from the current offset: a whole word if Src
+// has one left there (57 to 64 bits), otherwise the remaining bytes. It keeps
+// no bits past the logical stream, so reads never see the padding.
+func (r *Reader) refill() {
+	off := r.Offset()
+	i, sh := off>>3, uint(off&7)
+	var avail int
+	if len(r.Src)-i >= 8 {
+		r.acc = binary.LittleEndian.Uint64(r.Src[i:]) >> sh
+		avail = 64 - int(sh)
+	} else {
+		r.acc = 0
+		for k := i; k < len(r.Src); k++ {
+			r.acc |= uint64(r.Src[k]) << (8 * uint(k-i))
+		}
+		r.acc >>= sh
+		avail = max(8*(len(r.Src)-i)-int(sh), 0)
+	}
+	r.nbits = min(avail, r.total-off)
+	r.end = off + r.nbits
+}
+
+// readMasks[w] masks the lowest w bits (w in 0..64) without the shift-by-64
+// handling lowMask needs.
+var readMasks = func() (m [65]uint64) {
+	for w := range m {
+		m[w] = lowMask(w)
+	}
+	return m
+}()
+
+// ReadBits reads 'width' bits (0..64) from the source and returns them as a uint64.
+// If there are not enough bits in the accumulator, it refills from Src.
+// If the stream runs out of valid bits, it returns an *Error wrapping ErrShort;
+// a failed read does not consume anything.
+func (r *Reader) ReadBits(width int) (uint64, error) {
+	if uint(width) > uint(r.nbits) {
+		return r.readSlow(width)
+	}
+	// Fast path: the accumulator holds enough valid bits. A 64-bit read
+	// leaves acc unshifted, but also leaves nbits at 0, and refill overwrites it.
+	out := r.acc & readMasks[width]
+	r.acc >>= uint(width) & 63
+	r.nbits -= width
+	return out, nil
+}
+
+// readSlow is ReadBits when the accumulator must be refilled (or the read fails).
+func (r *Reader) readSlow(width int) (uint64, error) {
+	off := r.Offset()
+	// Enough valid bits must remain, both logically and in Src.
+	if width < 0 || width > 64 || r.total-off < width || 8*len(r.Src)-off < width {
+		return 0, r.shortRead(width)
+	}
+	r.refill()
+	if width > r.nbits {
+		// Only an unaligned read of more than 56 bits gets here: split it.
+		lo, _ := r.ReadBits(32)
+		hi, _ := r.ReadBits(width - 32)
+		return lo | hi<<32, nil
+	}
+	out := r.acc & lowMask(width)
+	r.acc >>= uint(width)
+	r.nbits -= width
+	return out, nil
+}
@@ -0,0 +1,491 @@
+package bitstream
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// mask returns a value with the lowest 'w' bits set to 1.
+func mask(w int) uint64 {
+	if w <= 0 {
+		return 0
+	}
+	return (1 << w) - 1
+}
+
+// bitsToBytes rounds up the bit length to whole bytes.
+func bitsToBytes(bits int) int {
+	return (bits + 7) / 8
+}
+
+// TestRoundTripFixedWidths verifies that a sequence of (value,width) pairs
+// written with Writer can be read back with the exact same widths using Reader.
+// This also stresses cross-byte boundaries (e.g., 3+5+9 bits etc.).
+func TestRoundTripFixedWidths(t *testing.T) {
+	type pair struct {
+		w int
+		v uint64
+	}
+	seq := []pair{
+		{1, 1},     // 1 bit
+		{2, 2},     // 10b
+		{3, 5},     // 101b
+		{5, 0x1F},  // 11111b
+		{7, 0x55},  // 1010101b
+		{8, 0xA5},  // full byte
+		{9, 0x1AB}, // crosses multiple bytes
+		{13, 0x1234 & mask(13)},
+		{16, 0xBEEF},
+	}
+
+	// Write
+	var w Writer
+	totalBits := 0
+	for _, p := range seq {
+		w.WriteBits(p.v, p.w)
+		totalBits += p.w
+	}
+	buf := w.Finish()
+
+	// Sanity: buffer length should match ceil(totalBits/8).
+	if got, want := len(buf), bitsToBytes(totalBits); got != want {
+		t.Fatalf("buffer length mismatch: got %d, want %d (totalBits=%d)", got, want, totalBits)
+	}
+
+	// Read back
+	r := NewReader(buf, totalBits)
+	for i, p := range seq {
+		got, err := r.ReadBits(p.w)
+		if err != nil {
+			t.Fatalf("ReadBits failed at step %d: %v", i, err)
+		}
+		want := p.v & mask(p.w) // high bits beyond width must be ignored
+		if got != want {
+			t.Fatalf("mismatch at step %d: got=0x%X want=0x%X (width=%d)",
+				i, got, want, p.w)
+		}
+	}
+}
+
+// TestFlushBehavior checks that Finish flushes a trailing partial byte exactly once
+// and does not add extra bytes when the bit count is already byte-aligned.
+func TestFlushBehavior(t *testing.T) {
+	// Case 1: not byte-aligned (13 bits)
+	var w1 Writer
+	w1.WriteBits(0x1FFF, 13)
+	buf1 := w1.Finish()
+	if len(buf1) != bitsToBytes(13) {
+		t.Fatalf("not byte-aligned: got %d bytes, want %d", len(buf1), bitsToBytes(13))
+	}
+	// Round-trip guarded
+	r1 := NewReader(buf1, 13)
+	if v, err := r1.ReadBits(13); err != nil || v != (0x1FFF&mask(13)) {
+		t.Fatalf("round-trip failed for 13 bits: v=0x%X err=%v", v, err)
+	}
+
+	// Case 2: already byte-aligned (16 bits)
+	var w2 Writer
+	w2.WriteBits(0xABCD, 16)
+	buf2 := w2.Finish()
+	if len(buf2) != bitsToBytes(16) {
+		t.Fatalf("byte-aligned: got %d bytes, want %d", len(buf2), bitsToBytes(16))
+	}
+	r2 := NewReader(buf2, 16)
+	if v, err := r2.ReadBits(16); err != nil || v != 0xABCD {
+		t.Fatalf("round-trip failed for 16 bits: v=0x%X err=%v", v, err)
+	}
+}
+
+// TestErrShort ensures Reader returns ErrShort when attempting to read past the end.
+func TestErrShort(t *testing.T) {
+	var w Writer
+	w.WriteBits(0b10101, 5) // write only 5 bits
+	buf := w.Finish()
+
+	r := NewReader(buf, 5)
+
+	// First read 3 bits: OK
+	if v, err := r.ReadBits(3); err != nil || v != 0b101 {
+		t.Fatalf("first read: v=%b err=%v", v, err)
+	}
+	// Second read 3 bits: only 2 bits left → should fail with ErrShort
+	if _, err := r.ReadBits(3); !errors.Is(err, ErrShort) {
+		t.Fatalf("expected ErrShort, got %v", err)
+	}
+}
+
+// TestOffset checks that Offset tracks consumed bits and is unchanged by a failed read.
+func TestOffset(t *testing.T) {
+	var w Writer
+	w.WriteBits(0x3FF, 10)
+	r := NewReader(w.Finish(), 10)
+	if r.Offset() != 0 {
+		t.Fatalf("initial offset: got %d", r.Offset())
+	}
+	if _, err := r.ReadBits(7); err != nil {
+		t.Fatalf("ReadBits failed: %v", err)
+	}
+	if r.Offset() != 7 {
+		t.Fatalf("offset after 7 bits: got %d", r.Offset())
+	}
+	if _, err := r.ReadBits(4); !errors.Is(err, ErrShort) {
+		t.Fatalf("expected ErrShort, got %v", err)
+	}
+	if r.Offset() != 7 {
+		t.Fatalf("offset moved on failed read: got %d", r.Offset())
+	}
+}
+
+// TestMasking verifies that WriteBits masks off any high bits beyond 'width'.
+func TestMasking(t *testing.T) {
+	var w Writer
+	w.WriteBits(0xFFFFFFFFFFFFFFFF, 5) // only lowest 5 bits should be stored
+	buf := w.Finish()
+
+	r := NewReader(buf, 5)
+	got, err := r.ReadBits(5)
+	if err != nil {
+		t.Fatalf("ReadBits failed: %v", err)
+	}
+	if want := uint64(0x1F); got != want {
+		t.Fatalf("masking mismatch: got=0x%X want=0x%X", got, want)
+	}
+}
+
+// TestRandomizedRoundTrip performs a deterministic randomized test over many
+// (value,width) pairs to exercise different boundary conditions and byte layouts.
+// This acts like a lightweight property test without external dependencies.
+func TestRandomizedRoundTrip(t *testing.T) {
+	seed := int64(42)
+	rng := rand.New(rand.NewSource(seed))
+
+	for iter := range 50 { // 50 independent runs
+		// Generate a random sequence of up to ~100 writes with widths in [1,16]
+		type pair struct {
+			w int
+			v uint64
+		}
+		var seq []pair
+		totalBits := 0
+		for range 100 {
+			w := 1 + rng.Intn(16)
+			v := rng.Uint64() & mask(w)
+			seq = append(seq, pair{w: w, v: v})
+			totalBits += w
+			// Keep test runtime small: stop early with some probability
+			if rng.Float64() < 0.05 {
+				break
+			}
+		}
+		// Write
+		var w Writer
+		for _, p := range seq {
+			w.WriteBits(p.v, p.w)
+		}
+		buf := w.Finish()
+
+		// Basic size sanity
+		if got, want := len(buf), bitsToBytes(totalBits); got != want {
+			t.Fatalf("iter %d: buf size mismatch: got %d want %d (bits=%d)", iter, got, want, totalBits)
+		}
+
+		// Read back
+		r := NewReader(buf, totalBits)
+		for i, p := range seq {
+			got, err := r.ReadBits(p.w)
+			if err != nil {
+				t.Fatalf("iter %d step %d: ReadBits error: %v", iter, i, err)
+			}
+			if want := p.v & mask(p.w); got != want {
+				t.Fatalf("iter %d step %d: mismatch got=0x%X want=0x%X (w=%d)", iter, i, got, want, p.w)
+			}
+		}
+	}
+
+	_ = time.Now() // keep linter calm if time imported in future tweaks
+}
+
+// refWriter is the previous byte-at-a-time writer, kept as a reference for
+// byte-identical output and as a benchmark baseline.
+type refWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits int
+}
+
+func (w *refWriter) WriteBits(v uint32, width int) {
+	w.acc |= uint64(v&((1<<width)-1)) << w.nbits
+	w.nbits += width
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc&0xff))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *refWriter) Finish() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc&0xff))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
+
+// refReader is the previous byte-at-a-time reader (benchmark baseline).
+type refReader struct {
+	src   []byte
+	acc   uint64
+	nbits int
+	cur   int
+	rem   int
+}
+
+func (r *refReader) ReadBits(width int) (uint32, error) {
+	if width < 0 || r.rem < width {
+		return 0, ErrShort
+	}
+	for r.nbits < width {
+		if r.cur >= len(r.src) {
+			return 0, ErrShort
+		}
+		r.acc |= uint64(r.src[r.cur]) << r.nbits
+		r.cur++
+		r.nbits += 8
+	}
+	out := uint32(r.acc & uint64((1<<width)-1))
+	r.acc >>= width
+	r.nbits -= width
+	r.rem -= width
+	return out, nil
+}
+
+// TestByteIdenticalToReference writes random sequences (widths 1..32) with both
+// writers and requires identical bytes.
+func TestByteIdenticalToReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for iter := range 200 {
+		var w Writer
+		var ref refWriter
+		n := rng.Intn(200)
+		for range n {
+			width := 1 + rng.Intn(32)
+			v := uint32(rng.Uint64())
+			w.WriteBits(uint64(v), width)
+			ref.WriteBits(v, width)
+		}
+		got, want := w.Finish(), ref.Finish()
+		if string(got) != string(want) {
+			t.Fatalf("iter %d: output differs\n got:  %x\n want: %x", iter, got, want)
+		}
+	}
+}
+
+// TestWideFields round-trips widths up to 64 bits at every alignment.
+func TestWideFields(t *testing.T) {
+	rng := rand.New(rand.NewSource(99))
+	for lead := range 64 {
+		var w Writer
+		w.WriteBits(rng.Uint64(), lead)
+		vals := make([]uint64, 0, 64)
+		for width := 1; width <= 64; width++ {
+			v := rng.Uint64()
+			vals = append(vals, v&mask(width))
+			w.WriteBits(v, width)
+		}
+		buf := w.Finish()
+		r := NewReader(buf, -1)
+		if _, err := r.ReadBits(lead); err != nil {
+			t.Fatalf("lead=%d: %v", lead, err)
+		}
+		for i, want := range vals {
+			got, err := r.ReadBits(i + 1)
+			if err != nil || got != want {
+				t.Fatalf("lead=%d width=%d: got 0x%X, %v; want 0x%X", lead, i+1, got, err, want)
+			}
+		}
+	}
+
+	// Whole-word reads empty the accumulator; stale bits must not leak into
+	// the zero-width and narrow reads that follow.
+	var w Writer
+	w.WriteBits(^uint64(0), 64)
+	w.WriteBits(0x0123456789ABCDEF, 64)
+	w.WriteBits(5, 3)
+	r := NewReader(w.Finish(), 131)
+	for i, c := range []struct {
+		width int
+		want  uint64
+	}{{64, ^uint64(0)}, {0, 0}, {64, 0x0123456789ABCDEF}, {0, 0}, {3, 5}} {
+		if got, err := r.ReadBits(c.width); err != nil || got != c.want {
+			t.Fatalf("read %d (%d bits): got 0x%X, %v; want 0x%X", i, c.width, got, err, c.want)
+		}
+	}
+	if _, err := r.ReadBits(1); !errors.Is(err, ErrShort) {
+		t.Fatalf("read past the end: %v", err)
+	}
+}
+
+// TestResetAndNewWriter checks buffer reuse and preallocation.
+func TestResetAndNewWriter(t *testing.T) {
+	w := NewWriter(100)
+	if cap(w.Buf) < 13 {
+		t.Fatalf("NewWriter(100): cap=%d, want >= 13", cap(w.Buf))
+	}
+	w.WriteBits(0xABC, 12)
+	first := string(w.Finish())
+	w.Reset()
+	if len(w.Buf) != 0 {
+		t.Fatalf("Reset kept %d bytes", len(w.Buf))
+	}
+	w.WriteBits(0xABC, 12)
+	if string(w.Finish()) != first {
+		t.Fatalf("output after Reset differs")
+	}
+
+	r := NewReader([]byte{0xFF}, 8)
+	_, _ = r.ReadBits(8)
+	r.Reset([]byte{0x01}, 1)
+	if v, err := r.ReadBits(1); err != nil || v != 1 || r.Offset() != 1 {
+		t.Fatalf("Reader.Reset: v=%d err=%v off=%d", v, err, r.Offset())
+	}
+}
+
+// benchWidths is a deck-like field mix: 16-bit header, 8-bit counts, 11-bit ordinals, 2-bit counts.
+var benchWidths = func() []int {
+	ws := []int{16, 8}
+	for range 4 {
+		ws = append(ws, 11)
+	}
+	ws = append(ws, 8)
+	for range 5 {
+		ws = append(ws, 11)
+	}
+	ws = append(ws, 8)
+	for range 40 {
+		ws = append(ws, 11, 2)
+	}
+	return ws
+}()
+
+func BenchmarkWriter(b *testing.B) {
+	w := NewWriter(1024)
+	for b.Loop() {
+		w.Reset()
+		for i, width := range benchWidths {
+			w.WriteBits(uint64(i)*2654435761, width)
+		}
+		w.Finish()
+	}
+}
+
+func BenchmarkRefWriter(b *testing.B) {
+	var w refWriter
+	for b.Loop() {
+		w.buf = w.buf[:0]
+		for i, width := range benchWidths {
+			w.WriteBits(uint32(i)*2654435761, width)
+		}
+		w.Finish()
+	}
+}
+
+func benchStream() ([]byte, int) {
+	var w Writer
+	bits := 0
+	for i, width := range benchWidths {
+		w.WriteBits(uint64(i), width)
+		bits += width
+	}
+	return w.Finish(), bits
+}
+
+func BenchmarkReader(b *testing.B) {
+	buf, bits := benchStream()
+	var r Reader
+	for b.Loop() {
+		r.Reset(buf, bits)
+		for _, width := range benchWidths {
+			if _, err := r.ReadBits(width); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRefReader(b *testing.B) {
+	buf, bits := benchStream()
+	for b.Loop() {
+		r := refReader{src: buf, rem: bits}
+		for _, width := range benchWidths {
+			if _, err := r.ReadBits(width); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// wideWidths writes 32-bit fields, where the old writer flushed four bytes per call.
+var wideWidths = func() []int {
+	ws := make([]int, 100)
+	for i := range ws {
+		ws[i] = 32
+	}
+	return ws
+}()
+
+func BenchmarkWriterWide(b *testing.B) {
+	w := NewWriter(len(wideWidths) * 32)
+	for b.Loop() {
+		w.Reset()
+		for i, width := range wideWidths {
+			w.WriteBits(uint64(i), width)
+		}
+		w.Finish()
+	}
+}
+
+func BenchmarkRefWriterWide(b *testing.B) {
+	var w refWriter
+	for b.Loop() {
+		w.buf = w.buf[:0]
+		for i, width := range wideWidths {
+			w.WriteBits(uint32(i), width)
+		}
+		w.Finish()
+	}
+}
+
+func BenchmarkReaderWide(b *testing.B) {
+	var w Writer
+	for i, width := range wideWidths {
+		w.WriteBits(uint64(i), width)
+	}
+	buf := w.Finish()
+	var r Reader
+	for b.Loop() {
+		r.Reset(buf, -1)
+		for _, width := range wideWidths {
+			if _, err := r.ReadBits(width); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRefReaderWide(b *testing.B) {
+	var w Writer
+	for i, width := range wideWidths {
+		w.WriteBits(uint64(i), width)
+	}
+	buf := w.Finish()
+	for b.Loop() {
+		r := refReader{src: buf, rem: len(buf) * 8}
+		for _, width := range wideWidths {
+			if _, err := r.ReadBits(width); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package bitstream
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The variable-length codes below are prefix codes built from WriteBits and
+// the unary code, so they compose freely with fixed-width fields:
+//
+//	unary(n)     n one-bits followed by a zero-bit
+//	gamma(v)     unary(N) then the low N bits of v, N = bits.Len64(v)-1 (v >= 1)
+//	delta(v)     gamma(N+1) then the low N bits of v, N = bits.Len64(v)-1 (v >= 1)
+//	rice(v, k)   unary(v>>k) then the low k bits of v
+//	uvarint(v)   LEB128 bytes (7 data bits + continuation bit), 8 bits each
+//	varint(v)    uvarint of the zigzag encoding of v
+
+// WriteBool writes a single bit (1 for true).
+func (w *Writer) WriteBool(b bool) {
+	var v uint64
+	if b {
+		v = 1
+	}
+	w.WriteBits(v, 1)
+}
+
+// WriteUnary writes n one-bits followed by a zero-bit.
+func (w *Writer) WriteUnary(n uint64) {
+	for ; n >= 63; n -= 63 {
+		w.WriteBits(1<<63-1, 63)
+	}
+	// n ones and the terminating zero in a single field.
+	w.WriteBits(1<<n-1, int(n)+1)
+}
+
+// WriteGamma writes v >= 1 as an Elias-gamma code. It panics if v is 0.
+func (w *Writer) WriteGamma(v uint64) {
+	if v == 0 {
+		panic("bitstream: WriteGamma of 0")
+	}
+	n := bits.Len64(v) - 1
+	w.WriteUnary(uint64(n))
+	w.WriteBits(v, n)
+}
+
+// WriteDelta writes v >= 1 as an Elias-delta code. It panics if v is 0.
+func (w *Writer) WriteDelta(v uint64) {
+	if v == 0 {
+		panic("bitstream: WriteDelta of 0")
+	}
+	n := bits.Len64(v) - 1
+	w.WriteGamma(uint64(n) + 1)
+	w.WriteBits(v, n)
+}
+
+// WriteRice writes v as a Golomb-Rice code with parameter k (0..63).
+func (w *Writer) WriteRice(v uint64, k int) {
+	w.WriteUnary(v >> uint(k))
+	w.WriteBits(v, k)
+}
+
+// WriteUvarint writes v as LEB128 (as encoding/binary.AppendUvarint), 8 bits per byte.
+func (w *Writer) WriteUvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	for _, b := range binary.AppendUvarint(buf[:0], v) {
+		w.WriteBits(uint64(b), 8)
+	}
+}
+
+// WriteVarint writes v as a zigzag LEB128 varint (as encoding/binary.AppendVarint).
+func (w *Writer) WriteVarint(v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	for _, b := range binary.AppendVarint(buf[:0], v) {
+		w.WriteBits(uint64(b), 8)
+	}
+}
+
+// ReadBool reads a single bit.
+func (r *Reader) ReadBool() (bool, error) {
+	v, err := r.ReadBits(1)
+	return v == 1, err
+}
+
+// ReadUnary reads a unary code: the number of one-bits before the next zero-bit.
+// On failure nothing is consumed.
+func (r *Reader) ReadUnary() (uint64, error) {
+	c := *r
+	off := r.Offset()
+	var n uint64
+	for {
+		w := min(64, c.BitsRemaining())
+		if w == 0 {
+			return 0, fail("unary", off, ErrShort)
+		}
+		v, err := c.Peek(w)
+		if err != nil {
+			return 0, fail("unary", off, ErrShort)
+		}
+		ones := bits.TrailingZeros64(^v)
+		if ones < w {
+			// Found the terminating zero.
+			_ = c.Skip(ones + 1)
+			*r = c
+			return n + uint64(ones), nil
+		}
+		_ = c.Skip(w)
+		n += uint64(w)
+	}
+}
+
+// ReadGamma reads an Elias-gamma code. On failure nothing is consumed.
+func (r *Reader) ReadGamma() (uint64, error) {
+	c := *r
+	off := r.Offset()
+	n, err := c.ReadUnary()
+	if err != nil {
+		return 0, fail("gamma", off, ErrShort)
+	}
+	if n > 63 {
+		return 0, fail("gamma", off, ErrOverflow)
+	}
+	low, err := c.ReadBits(int(n))
+	if err != nil {
+		return 0, fail("gamma", off, ErrShort)
+	}
+	*r = c
+	return 1<<n | low, nil
+}
+
+// ReadDelta reads an Elias-delta code. On failure nothing is consumed.
+func (r *Reader) ReadDelta() (uint64, error) {
+	c := *r
+	off := r.Offset()
+	n1, err := c.ReadGamma()
+	if err != nil {
+		return 0, fail("delta", off, errOf(err))
+	}
+	n := n1 - 1
+	if n > 63 {
+		return 0, fail("delta", off, ErrOverflow)
+	}
+	low, err := c.ReadBits(int(n))
+	if err != nil {
+		return 0, fail("delta", off, ErrShort)
+	}
+	*r = c
+	return 1<<n | low, nil
+}
+
+// ReadRice reads a Golomb-Rice code with parameter k (0..63). On failure nothing is consumed.
+func (r *Reader) ReadRice(k int) (uint64, error) {
+	c := *r
+	off := r.Offset()
+	q, err := c.ReadUnary()
+	if err != nil {
+		return 0, fail("rice", off, ErrShort)
+	}
+	if k > 0 && q > (1<<(64-uint(k)))-1 {
+		return 0, fail("rice", off, ErrOverflow)
+	}
+	low, err := c.ReadBits(k)
+	if err != nil {
+		return 0, fail("rice", off, ErrShort)
+	}
+	*r = c
+	return q<<uint(k) | low, nil
+}
+
+// ReadUvarint reads a LEB128 value written by WriteUvarint. On failure nothing is consumed.
+func (r *Reader) ReadUvarint() (uint64, error) {
+	c := *r
+	off := r.Offset()
+	var buf [binary.MaxVarintLen64]byte
+	for i := range buf {
+		b, err := c.ReadBits(8)
+		if err != nil {
+			return 0, fail("uvarint", off, ErrShort)
+		}
+		buf[i] = byte(b)
+		if b < 0x80 {
+			v, n := binary.Uvarint(buf[:i+1])
+			if n <= 0 {
+				return 0, fail("uvarint", off, ErrOverflow)
+			}
+			*r = c
+			return v, nil
+		}
+	}
+	return 0, fail("uvarint", off, ErrOverflow)
+}
+
+// ReadVarint reads a zigzag LEB128 value written by WriteVarint. On failure nothing is consumed.
+func (r *Reader) ReadVarint() (int64, error) {
+	off := r.Offset()
+	u, err := r.ReadUvarint()
+	if err != nil {
+		return 0, fail("varint", off, errOf(err))
+	}
+	// Undo zigzag, as encoding/binary.Varint does.
+	v := int64(u >> 1)
+	if u&1 != 0 {
+		v = ^v
+	}
+	return v, nil
+}
+
+// errOf returns the sentinel wrapped by err (ErrShort or ErrOverflow).
+func errOf(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Err
+	}
+	return err
+}
@@ -0,0 +1,197 @@
+package bitstream
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// codeCase writes one value with a variable-length code and reads it back.
+type codeCase struct {
+	name  string
+	write func(w *Writer, v uint64)
+	read  func(r *Reader) (uint64, error)
+	min   uint64
+}
+
+var codeCases = []codeCase{
+	{"unary", func(w *Writer, v uint64) { w.WriteUnary(v % 300) }, (*Reader).ReadUnary, 0},
+	{"gamma", (*Writer).WriteGamma, (*Reader).ReadGamma, 1},
+	{"delta", (*Writer).WriteDelta, (*Reader).ReadDelta, 1},
+	{"rice0", func(w *Writer, v uint64) { w.WriteRice(v%100, 0) }, func(r *Reader) (uint64, error) { return r.ReadRice(0) }, 0},
+	{"rice5", func(w *Writer, v uint64) { w.WriteRice(v%100000, 5) }, func(r *Reader) (uint64, error) { return r.ReadRice(5) }, 0},
+	{"rice60", func(w *Writer, v uint64) { w.WriteRice(v, 60) }, func(r *Reader) (uint64, error) { return r.ReadRice(60) }, 0},
+	{"uvarint", (*Writer).WriteUvarint, (*Reader).ReadUvarint, 0},
+}
+
+// TestCodesRoundTrip interleaves each code with odd-width fixed fields so
+// values straddle word and byte boundaries.
+func TestCodesRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, tc := range codeCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vals := []uint64{tc.min, tc.min + 1, 2, 3, 7, 8, 255, 256, 1 << 32, math.MaxUint64}
+			for range 500 {
+				vals = append(vals, rnd.Uint64()>>uint(rnd.Intn(64)))
+			}
+			var w Writer
+			want := make([]uint64, 0, len(vals))
+			for _, v := range vals {
+				v = max(v, tc.min)
+				tc.write(&w, v)
+				w.WriteBits(0b101, 3)
+				want = append(want, v)
+			}
+			n := w.Len()
+			r := NewReader(w.Finish(), n)
+			for i, v := range want {
+				got, err := tc.read(&r)
+				if err != nil {
+					t.Fatalf("#%d: %v", i, err)
+				}
+				// Codes that reduce the input modulo something are checked via a re-encode.
+				var a, b Writer
+				tc.write(&a, v)
+				if a.Len() == 0 {
+					t.Fatalf("#%d: empty code", i)
+				}
+				tc.write(&b, got)
+				if string(a.Finish()) != string(b.Finish()) {
+					t.Fatalf("#%d: got %d, want %d", i, got, v)
+				}
+				if tail, _ := r.ReadBits(3); tail != 0b101 {
+					t.Fatalf("#%d: lost sync, tail=%b", i, tail)
+				}
+			}
+			if r.BitsRemaining() != 0 {
+				t.Fatalf("%d bits left over", r.BitsRemaining())
+			}
+		})
+	}
+}
+
+func TestCodeLengths(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w *Writer)
+		bits  int
+	}{
+		{"unary 0", func(w *Writer) { w.WriteUnary(0) }, 1},
+		{"unary 70", func(w *Writer) { w.WriteUnary(70) }, 71},
+		{"gamma 1", func(w *Writer) { w.WriteGamma(1) }, 1},
+		{"gamma 5", func(w *Writer) { w.WriteGamma(5) }, 5},
+		{"delta 1", func(w *Writer) { w.WriteDelta(1) }, 1},
+		{"delta 10", func(w *Writer) { w.WriteDelta(10) }, 8},
+		{"rice 9 k=2", func(w *Writer) { w.WriteRice(9, 2) }, 5},
+		{"uvarint 300", func(w *Writer) { w.WriteUvarint(300) }, 16},
+		{"bool", func(w *Writer) { w.WriteBool(true) }, 1},
+	}
+	for _, tt := range tests {
+		var w Writer
+		tt.write(&w)
+		if w.Len() != tt.bits {
+			t.Errorf("%s: %d bits, want %d", tt.name, w.Len(), tt.bits)
+		}
+	}
+}
+
+func TestVarintMatchesEncodingBinary(t *testing.T) {
+	for _, v := range []int64{0, 1, -1, 63, -64, 64, math.MaxInt64, math.MinInt64} {
+		var w Writer
+		w.WriteVarint(v)
+		b := w.Finish()
+		if want := binary.AppendVarint(nil, v); string(b) != string(want) {
+			t.Fatalf("WriteVarint(%d) = %x, want %x", v, b, want)
+		}
+		r := NewReader(b, len(b)*8)
+		if got, err := r.ReadVarint(); err != nil || got != v {
+			t.Fatalf("ReadVarint = %d, %v; want %d", got, err, v)
+		}
+	}
+}
+
+func TestCodeErrors(t *testing.T) {
+	// Truncated gamma: unary prefix promises 3 low bits, only 1 present.
+	var w Writer
+	w.WriteBits(0b0111, 4)
+	w.WriteBits(1, 1)
+	r := NewReader(w.Finish(), 5)
+	_, err := r.ReadGamma()
+	var e *Error
+	if !errors.As(err, &e) || !errors.Is(err, ErrShort) || e.Offset != 0 || e.Op != "gamma" {
+		t.Fatalf("ReadGamma: %v", err)
+	}
+	if r.Offset() != 0 {
+		t.Fatalf("failed read consumed %d bits", r.Offset())
+	}
+
+	// A 64-bit unary prefix can never start a valid gamma code.
+	all := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	r = NewReader(append(all[:8:8], 0), 72)
+	if _, err := r.ReadGamma(); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("ReadGamma overflow: %v", err)
+	}
+	r = NewReader(all, 72)
+	if _, err := r.ReadUnary(); !errors.Is(err, ErrShort) {
+		t.Fatalf("ReadUnary without terminator: %v", err)
+	}
+
+	// Eleven continuation bytes overflow a uvarint.
+	over := append(append([]byte{}, all...), 0xFF, 0x01)
+	r = NewReader(over, len(over)*8)
+	if _, err := r.ReadUvarint(); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("ReadUvarint overflow: %v", err)
+	}
+
+	r = NewReader([]byte{0}, 3)
+	_, _ = r.ReadBits(1)
+	_, err = r.ReadBits(4)
+	if !errors.As(err, &e) || e.Offset != 1 || e.Error() != "bitstream: read 4 bits at bit 1: bitstream: unexpected EOF" {
+		t.Fatalf("ReadBits error: %v", err)
+	}
+}
+
+func TestPeekSkipAlign(t *testing.T) {
+	var w Writer
+	w.WriteBits(0b101, 3)
+	w.Align()
+	if w.Len() != 8 {
+		t.Fatalf("Writer.Align: len=%d", w.Len())
+	}
+	w.WriteBits(0xCD, 8)
+	r := NewReader(w.Finish(), 16)
+
+	if v, err := r.Peek(3); err != nil || v != 0b101 || r.Offset() != 0 {
+		t.Fatalf("Peek: v=%b err=%v off=%d", v, err, r.Offset())
+	}
+	if err := r.Skip(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Align(); err != nil || r.Offset() != 8 {
+		t.Fatalf("Align: err=%v off=%d", err, r.Offset())
+	}
+	if err := r.Align(); err != nil || r.Offset() != 8 {
+		t.Fatalf("Align when aligned: err=%v off=%d", err, r.Offset())
+	}
+	if err := r.Skip(9); !errors.Is(err, ErrShort) || r.Offset() != 8 {
+		t.Fatalf("Skip past end: err=%v off=%d", err, r.Offset())
+	}
+	if v, _ := r.ReadBits(8); v != 0xCD {
+		t.Fatalf("after Skip: %x", v)
+	}
+	if ok, err := r.ReadBool(); ok || !errors.Is(err, ErrShort) {
+		t.Fatalf("ReadBool at end: %v %v", ok, err)
+	}
+}
+
+func TestGammaZeroPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WriteGamma(0) did not panic")
+		}
+	}()
+	var w Writer
+	w.WriteGamma(0)
+}
@@ -0,0 +1,149 @@
+// cmd/deckpack/main.go
+//
+// deckpack converts and compares pack files.
+//
+//	deckpack convert [-to json|bin] [-compact] <in> <out>
+//	deckpack diff [-json] <old> <new>
+//
+// The input format (JSON, binary, or either gzip-compressed) is detected from its
+// content; the output format comes from -to, or else from the output extension
+// (.bin / .dcpk for binary, JSON otherwise). -compact writes JSON cards as
+// cards_b64; without it they are written as a decimal array, whatever the
+// input used. Cards are written in ordinal order, so both directions keep
+// every ordinal, and therefore every deck code, unchanged.
+//
+// diff prints DiffPacks for two pack files in any supported format: added and
+// removed cards, ordinal shifts, the id_bits change and the impact on codes.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Argonauts-inc/deckcodec"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "convert":
+		err = convert(os.Args[2:])
+	case "diff":
+		err = diff(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "deckpack:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: deckpack convert [-to json|bin] [-compact] <in> <out>")
+	fmt.Fprintln(os.Stderr, "       deckpack diff [-json] <old> <new>")
+	os.Exit(2)
+}
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := fs.String("to", "", "output format: json or bin (default: from the output extension)")
+	compact := fs.Bool("compact", false, "write JSON cards as cards_b64 instead of a decimal array")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+
+	p, err := readPack(in)
+	if err != nil {
+		return fmt.Errorf("%s: %w", in, err)
+	}
+	format := *to
+	if format == "" {
+		format = "json"
+		if ext := strings.ToLower(filepath.Ext(out)); ext == ".bin" || ext == ".dcpk" {
+			format = "bin"
+		}
+	}
+
+	var b []byte
+	switch format {
+	case "bin":
+		b, err = p.MarshalBinary()
+	case "json":
+		p.CompactCards = *compact
+		b, err = json.MarshalIndent(p, "", "  ")
+		b = append(b, '\n')
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(out, b, 0o644)
+}
+
+func diff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "write the diff as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	oldPack, err := readPack(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	newPack, err := readPack(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(1), err)
+	}
+	d := deckcodec.DiffPacks(oldPack, newPack)
+	if *asJSON {
+		return d.WriteJSON(os.Stdout)
+	}
+	return d.WriteText(os.Stdout)
+}
+
+// readPack reads a JSON, gzip-compressed JSON or binary pack file. Sorted
+// packs must already be sorted: ParsePack would sort them silently, changing
+// ordinals.
+func readPack(path string) (deckcodec.Pack, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return deckcodec.Pack{}, err
+	}
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return deckcodec.Pack{}, err
+		}
+		if b, err = io.ReadAll(zr); err != nil {
+			return deckcodec.Pack{}, err
+		}
+	}
+	if deckcodec.IsBinaryPack(b) {
+		return deckcodec.ParsePackBinary(bytes.NewReader(b))
+	}
+	var raw deckcodec.Pack
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return deckcodec.Pack{}, err
+	}
+	if !raw.AppendOnly && !slices.IsSorted(raw.Cards) {
+		return deckcodec.Pack{}, errors.New("cards are not sorted; converting would change ordinals")
+	}
+	return deckcodec.ParsePack(bytes.NewReader(b))
+}
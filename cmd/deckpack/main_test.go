@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestConvert_CompactBothWays checks that -compact switches JSON cards to
+// cards_b64 and that converting without it expands them back.
+func TestConvert_CompactBothWays(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.json")
+	if err := os.WriteFile(plain, []byte(`{"format_id":5,"append_only":true,"cards":[30,10,20]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	compact := filepath.Join(dir, "compact.json")
+	expanded := filepath.Join(dir, "expanded.json")
+	for _, c := range []struct {
+		args      []string
+		out       string
+		b64, list bool
+	}{
+		{[]string{"-compact", plain, compact}, compact, true, false},
+		{[]string{compact, expanded}, expanded, false, true},
+	} {
+		if err := convert(c.args); err != nil {
+			t.Fatalf("convert %v: %v", c.args, err)
+		}
+		b, err := os.ReadFile(c.out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(b, []byte(`"cards_b64"`)) != c.b64 || bytes.Contains(b, []byte(`"cards":`)) != c.list {
+			t.Fatalf("convert %v wrote:\n%s", c.args, b)
+		}
+		p, err := readPack(c.out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.AppendOnly || !slices.Equal(p.Cards, []uint64{30, 10, 20}) {
+			t.Fatalf("convert %v: read back %+v", c.args, p)
+		}
+	}
+}
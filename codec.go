@@ -0,0 +1,136 @@
+package deckcodec
+
+import "slices"
+
+// Codec is a pack compiled for repeated use: the pack is validated once and
+// PK lookups go through a hash index instead of a binary search.
+// A Codec is immutable and safe for concurrent use by multiple goroutines.
+type Codec struct {
+	d        dict
+	reprints []ReprintClass
+	retired  []uint64
+}
+
+// NewCodec validates p and builds a Codec for it. Unlike Encode, which accepts
+// any ascending card list, NewCodec requires Cards to be strictly ascending
+// (sorted and de-duplicated) so every PK maps to exactly one ordinal.
+// Append-only packs may be in any order but must not repeat a PK.
+// The card list is copied; later changes to p do not affect the Codec.
+func NewCodec(p Pack) (*Codec, error) {
+	if p.FormatID == 0 {
+		return nil, &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
+	}
+	if len(p.Cards) == 0 {
+		return nil, &PackError{FormatID: p.FormatID, Reason: "empty pack", Err: ErrInvalidPack}
+	}
+	cards := slices.Clone(p.Cards)
+	index := make(map[uint64]uint32, len(cards))
+	for i, pk := range cards {
+		if p.AppendOnly {
+			if _, dup := index[pk]; dup {
+				return nil, &PackError{FormatID: p.FormatID, Reason: "duplicate card PK", Err: ErrInvalidPack}
+			}
+			index[pk] = uint32(i)
+			continue
+		}
+		if i > 0 && pk <= cards[i-1] {
+			reason := "cards not sorted"
+			if pk == cards[i-1] {
+				reason = "duplicate card PK"
+			}
+			return nil, &PackError{FormatID: p.FormatID, Reason: reason, Err: ErrInvalidPack}
+		}
+		index[pk] = uint32(i)
+	}
+	if err := checkReprints(p); err != nil {
+		return nil, err
+	}
+	if err := checkRetired(p); err != nil {
+		return nil, err
+	}
+	reprints, retired := cloneReprints(p.Reprints), slices.Clone(p.Retired)
+	return &Codec{reprints: reprints, retired: retired, d: dict{
+		fid:      p.FormatID,
+		cards:    cards,
+		ib:       idBits(len(cards)),
+		index:    index,
+		epoch:    p.AppendOnly,
+		fps:      prefixFingerprints(p.FormatID, cards),
+		reprints: reprints,
+		canon:    reprintIndex(Pack{Reprints: reprints}),
+
+		retired:    retired,
+		retiredSet: retiredIndex(Pack{Retired: retired}),
+	}}, nil
+}
+
+// prefixFingerprints returns the fingerprints of every prefix of cards.
+func prefixFingerprints(fid uint16, cards []uint64) []uint16 {
+	fps := make([]uint16, len(cards))
+	f := newFingerprinter(fid)
+	for i, pk := range cards {
+		f.add(pk)
+		fps[i] = f.sum()
+	}
+	return fps
+}
+
+// FormatID returns the format ID of the compiled pack.
+func (c *Codec) FormatID() uint16 { return c.d.fid }
+
+// Pack returns a copy of the compiled pack's cards, reprint classes and
+// retired cards as a Pack (metadata is not retained).
+func (c *Codec) Pack() Pack {
+	return Pack{
+		FormatID:   c.d.fid,
+		AppendOnly: c.d.epoch,
+		Cards:      slices.Clone(c.d.cards),
+		Reprints:   cloneReprints(c.reprints),
+		Retired:    slices.Clone(c.retired),
+	}
+}
+
+// Encode is Encode against the compiled pack.
+func (c *Codec) Encode(in DeckInput) (string, error) {
+	return c.d.encodeString(in)
+}
+
+// EncodeWithOpts is EncodeWithOpts against the compiled pack.
+func (c *Codec) EncodeWithOpts(in DeckInput, opts EncodeOpts) (string, error) {
+	d := c.d
+	d.withFP = opts.Fingerprint
+	d.allowRetired = opts.AllowRetired
+	return d.encodeString(in)
+}
+
+// AppendEncode is AppendEncode against the compiled pack.
+func (c *Codec) AppendEncode(dst []byte, in DeckInput) ([]byte, error) {
+	return c.d.appendEncode(dst, in)
+}
+
+// EncodeBytes is EncodeBytes against the compiled pack.
+func (c *Codec) EncodeBytes(in DeckInput) ([]byte, error) {
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	return c.d.appendRaw(nil, in, sc)
+}
+
+// Decode is Decode against the compiled pack.
+func (c *Codec) Decode(code string) (DeckOutput, error) {
+	return c.d.decodeString(code, DecodeOpts{})
+}
+
+// DecodeInto is DecodeInto against the compiled pack.
+func (c *Codec) DecodeInto(code string, out *DeckOutput) error {
+	return c.d.decodeStringInto(code, DecodeOpts{}, out)
+}
+
+// DecodeBytes is DecodeBytes against the compiled pack.
+func (c *Codec) DecodeBytes(raw []byte) (DeckOutput, error) {
+	return c.d.decode(raw, DecodeOpts{}, nil)
+}
+
+// DecodeWithOpts is DecodeWithOpts against the compiled pack.
+func (c *Codec) DecodeWithOpts(code string, opts DecodeOpts) (DeckOutput, error) {
+	return c.d.decodeString(code, opts)
+}
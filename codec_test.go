@@ -0,0 +1,210 @@
+package deckcodec
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// benchPack returns a pack of m ascending PKs (1000, 1007, 1014, ...).
+func benchPack(fid uint16, m int) Pack {
+	cards := make([]uint64, m)
+	for i := range cards {
+		cards[i] = uint64(1000 + 7*i)
+	}
+	return Pack{FormatID: fid, Cards: cards}
+}
+
+// benchDeck returns a full-size deck (4 leaders, 5 tactics, 40 uniques) for benchPack.
+func benchDeck() DeckInput {
+	in := DeckInput{Deck: make(map[uint64]uint8, 40)}
+	for i := range 4 {
+		in.Leader = append(in.Leader, uint64(1000+7*(1500-i*11)))
+	}
+	for i := range 5 {
+		in.Tactics = append(in.Tactics, uint64(1000+7*(900+i*13)))
+	}
+	for i := range 40 {
+		in.Deck[uint64(1000+7*(i*37))] = uint8(1 + i%4)
+	}
+	return in
+}
+
+// TestCodec_MatchesFreeFunctions checks byte-identical codes and equal decodes.
+func TestCodec_MatchesFreeFunctions(t *testing.T) {
+	p := benchPack(3, 2000)
+	c, err := NewCodec(p)
+	if err != nil {
+		t.Fatalf("NewCodec failed: %v", err)
+	}
+	if c.FormatID() != 3 {
+		t.Fatalf("FormatID: got %d", c.FormatID())
+	}
+	in := benchDeck()
+	want, err := Encode(p, in)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	got, err := c.Encode(in)
+	if err != nil || got != want {
+		t.Fatalf("Codec.Encode: got %q, %v; want %q", got, err, want)
+	}
+	out, err := c.Decode(got)
+	if err != nil {
+		t.Fatalf("Codec.Decode failed: %v", err)
+	}
+	if !equalDeckCounts(out.Deck, in.Deck) || len(out.Leader) != 4 || len(out.Tactics) != 5 {
+		t.Fatalf("round-trip mismatch: %+v", out)
+	}
+
+	// The Codec keeps its own copy of the cards.
+	p.Cards[0] = 1
+	if _, err := c.Decode(got); err != nil {
+		t.Fatalf("Codec affected by caller mutation: %v", err)
+	}
+
+	// Typed errors behave as with the free functions.
+	if _, err := c.Encode(DeckInput{Leader: []uint64{5}}); !errors.Is(err, ErrUnknownCard) {
+		t.Fatalf("expected ErrUnknownCard, got %v", err)
+	}
+}
+
+// TestNewCodec_Validation rejects packs the free functions would silently misuse.
+func TestNewCodec_Validation(t *testing.T) {
+	cases := map[string]Pack{
+		"zero format": {Cards: []uint64{1, 2}},
+		"empty":       {FormatID: 1},
+		"unsorted":    {FormatID: 1, Cards: []uint64{1, 3, 2}},
+		"duplicate":   {FormatID: 1, Cards: []uint64{1, 2, 2}},
+	}
+	for name, p := range cases {
+		if _, err := NewCodec(p); !errors.Is(err, ErrInvalidPack) {
+			t.Fatalf("%s: expected ErrInvalidPack, got %v", name, err)
+		}
+	}
+}
+
+// TestCodec_Concurrent exercises one Codec from many goroutines (run with -race).
+func TestCodec_Concurrent(t *testing.T) {
+	c, err := NewCodec(benchPack(1, 500))
+	if err != nil {
+		t.Fatalf("NewCodec failed: %v", err)
+	}
+	in := DeckInput{Leader: []uint64{1000, 1007}, Deck: map[uint64]uint8{1014: 2, 1021: 4}}
+	want, _ := c.Encode(in)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				code, err := c.Encode(in)
+				if err == nil && code != want {
+					err = errors.New("non-deterministic code " + code)
+				}
+				if err == nil {
+					_, err = c.Decode(code)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	p, in := benchPack(1, 2000), benchDeck()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Encode(p, in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodeAppendOnly encodes against a large append-only pack, which
+// Encode searches through the PK order BuildPack records.
+func BenchmarkEncodeAppendOnly(b *testing.B) {
+	sorted := benchPack(1, 60000)
+	cards := slices.Clone(sorted.Cards)
+	slices.Reverse(cards)
+	p, err := BuildPack(cards, PackBuildOpts{FormatID: 1, AppendOnly: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	in := benchDeck()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Encode(p, in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	c, _ := NewCodec(benchPack(1, 2000))
+	in := benchDeck()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := c.Encode(in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	p := benchPack(1, 2000)
+	code, _ := Encode(p, benchDeck())
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Decode(p, code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	c, _ := NewCodec(benchPack(1, 2000))
+	code, _ := c.Encode(benchDeck())
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := c.Decode(code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecAppendEncode(b *testing.B) {
+	c, _ := NewCodec(benchPack(1, 2000))
+	in := benchDeck()
+	dst := make([]byte, 0, 128)
+	b.ReportAllocs()
+	for b.Loop() {
+		var err error
+		if dst, err = c.AppendEncode(dst[:0], in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecDecodeInto(b *testing.B) {
+	c, _ := NewCodec(benchPack(1, 2000))
+	code, _ := c.Encode(benchDeck())
+	var out DeckOutput
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := c.DecodeInto(code, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
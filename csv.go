@@ -0,0 +1,167 @@
+package deckcodec
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CSVPackOpts controls BuildPackFromCSV. The zero value reads a header row
+// with a "pk" column and keeps every row.
+type CSVPackOpts struct {
+	// Build is passed to BuildPack with the PKs of the kept rows, in row order.
+	Build PackBuildOpts
+
+	// Column names in the header row, matched case-insensitively.
+	// Defaults: "pk", "set" and "type". The set and type columns are only
+	// required when a filter or SectionTypes uses them.
+	PKColumn   string
+	SetColumn  string
+	TypeColumn string
+
+	// Comma is the field delimiter (default ',').
+	Comma rune
+
+	// Sets and Types keep only rows whose set code or card type is listed.
+	// A nil filter keeps every row.
+	Sets  []string
+	Types []string
+
+	// SectionTypes maps card types to the section a card may be used in.
+	// If set, every kept row's type must be listed, and BuildPackFromCSV
+	// returns the section of each card.
+	SectionTypes map[string]Section
+}
+
+// CSVRowError reports a problem with one CSV row. Line is 1-based, as in the
+// file, and Column is the header name of the offending field (empty if the
+// whole row is at fault).
+type CSVRowError struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *CSVRowError) Error() string {
+	msg := "deckcodec: line " + strconv.Itoa(e.Line)
+	if e.Column != "" {
+		msg += ", column " + strconv.Quote(e.Column)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *CSVRowError) Unwrap() error { return e.Err }
+
+// BuildPackFromCSV reads a card export and builds a pack from it with
+// BuildPack, so the result is the Pack that BuildPack returns for the same
+// PKs and opts.Build. Rows are filtered by opts.Sets and opts.Types.
+//
+// Every bad row is reported, not just the first: the error is a *PackError
+// (ErrInvalidPack) whose Cause joins a *CSVRowError per row.
+// If opts.SectionTypes is set, the second result maps each card to its section.
+func BuildPackFromCSV(r io.Reader, opts CSVPackOpts) (Pack, map[uint64]Section, error) {
+	fid := opts.Build.FormatID
+	fail := func(reason string, cause error) (Pack, map[uint64]Section, error) {
+		return Pack{}, nil, &PackError{FormatID: fid, Reason: reason, Err: ErrInvalidPack, Cause: cause}
+	}
+
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return fail("CSV header", err)
+	}
+	header = slices.Clone(header) // ReuseRecord overwrites it
+
+	// column returns the index of the named column (or def), or -1.
+	column := func(name, def string) int {
+		if name == "" {
+			name = def
+		}
+		return slices.IndexFunc(header, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), name) })
+	}
+	pkCol := column(opts.PKColumn, "pk")
+	setCol := column(opts.SetColumn, "set")
+	typeCol := column(opts.TypeColumn, "type")
+	switch {
+	case pkCol < 0:
+		return fail("CSV header has no PK column", nil)
+	case opts.Sets != nil && setCol < 0:
+		return fail("CSV header has no set column to filter on", nil)
+	case (opts.Types != nil || opts.SectionTypes != nil) && typeCol < 0:
+		return fail("CSV header has no type column", nil)
+	}
+	name := func(col int) string { return strings.TrimSpace(header[col]) }
+
+	var (
+		pks      []uint64
+		sections map[uint64]Section
+		rowErrs  []error
+	)
+	if opts.SectionTypes != nil {
+		sections = make(map[uint64]Section)
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if !errors.As(err, &pe) {
+				return fail("read CSV", err)
+			}
+			rowErrs = append(rowErrs, &CSVRowError{Line: pe.Line, Err: pe.Err})
+			if !errors.Is(pe.Err, csv.ErrFieldCount) {
+				// The reader cannot resynchronize after a quoting error.
+				break
+			}
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(col int) string { return strings.TrimSpace(rec[col]) }
+
+		if opts.Sets != nil && !slices.Contains(opts.Sets, field(setCol)) {
+			continue
+		}
+		if opts.Types != nil && !slices.Contains(opts.Types, field(typeCol)) {
+			continue
+		}
+		pk, err := strconv.ParseUint(field(pkCol), 10, 64)
+		if err != nil {
+			rowErrs = append(rowErrs, &CSVRowError{Line: line, Column: name(pkCol), Err: err})
+			continue
+		}
+		if sections != nil {
+			typ := field(typeCol)
+			sec, ok := opts.SectionTypes[typ]
+			if !ok {
+				rowErrs = append(rowErrs, &CSVRowError{Line: line, Column: name(typeCol), Err: errors.New("no section for type " + strconv.Quote(typ))})
+				continue
+			}
+			if prev, seen := sections[pk]; seen && prev != sec {
+				rowErrs = append(rowErrs, &CSVRowError{Line: line, Column: name(typeCol),
+					Err: errors.New("pk " + strconv.FormatUint(pk, 10) + " already has section " + prev.String())})
+				continue
+			}
+			sections[pk] = sec
+		}
+		pks = append(pks, pk)
+	}
+	if len(rowErrs) > 0 {
+		return fail(strconv.Itoa(len(rowErrs))+" bad CSV row(s)", errors.Join(rowErrs...))
+	}
+
+	p, err := BuildPack(pks, opts.Build)
+	if err != nil {
+		return Pack{}, nil, err
+	}
+	return p, sections, nil
+}
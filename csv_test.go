@@ -0,0 +1,123 @@
+package deckcodec
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const cardsCSV = `PK, Set, Type, Name
+412,ST01,Leader,"Captain, the First"
+205,ST01,Unit,Scout
+101,ST02,Unit,Runner
+303,ST02,Tactic,Ambush
+205,ST01,Unit,Scout (promo)
+`
+
+// TestBuildPackFromCSV_SameAsBuildPack checks that the CSV loader produces
+// exactly what BuildPack produces for the same PKs, sorted and append-only.
+func TestBuildPackFromCSV_SameAsBuildPack(t *testing.T) {
+	for _, build := range []PackBuildOpts{
+		{FormatID: 3, Name: "Std", Deduplicate: true},
+		{FormatID: 4, AppendOnly: true},
+	} {
+		got, sections, err := BuildPackFromCSV(strings.NewReader(cardsCSV), CSVPackOpts{Build: build})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := BuildPack([]uint64{412, 205, 101, 303, 205}, build)
+		if PackHash(got) != PackHash(want) || got.Name != want.Name || !slices.Equal(got.Cards, want.Cards) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+		if sections != nil {
+			t.Fatalf("sections without SectionTypes: %v", sections)
+		}
+	}
+}
+
+func TestBuildPackFromCSV_FiltersAndSections(t *testing.T) {
+	opts := CSVPackOpts{
+		Build:        PackBuildOpts{FormatID: 3, Deduplicate: true},
+		Sets:         []string{"ST02"},
+		SectionTypes: map[string]Section{"Leader": SectionLeader, "Tactic": SectionTactics, "Unit": SectionDeck},
+	}
+	p, sections, err := BuildPackFromCSV(strings.NewReader(cardsCSV), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Cards, []uint64{101, 303}) {
+		t.Fatalf("set filter: %v", p.Cards)
+	}
+	if !maps.Equal(sections, map[uint64]Section{101: SectionDeck, 303: SectionTactics}) {
+		t.Fatalf("sections: %v", sections)
+	}
+
+	opts.Sets, opts.Types = nil, []string{"Leader", "Tactic"}
+	if p, _, err = BuildPackFromCSV(strings.NewReader(cardsCSV), opts); err != nil || !slices.Equal(p.Cards, []uint64{303, 412}) {
+		t.Fatalf("type filter: %v, %v", p.Cards, err)
+	}
+
+	// Custom column names and delimiter.
+	tsv := "id\tkind\n7\ta\n9\tb\n"
+	p, _, err = BuildPackFromCSV(strings.NewReader(tsv), CSVPackOpts{
+		Build: PackBuildOpts{FormatID: 1}, PKColumn: "ID", TypeColumn: "kind", Comma: '\t', Types: []string{"b"},
+	})
+	if err != nil || !slices.Equal(p.Cards, []uint64{9}) {
+		t.Fatalf("custom columns: %v, %v", p.Cards, err)
+	}
+}
+
+// TestBuildPackFromCSV_RowErrors checks that every bad row is reported with
+// its line number.
+func TestBuildPackFromCSV_RowErrors(t *testing.T) {
+	src := `pk,set,type
+1,A,Unit
+x,A,Unit
+3,A,Spell
+4,A
+5,A,Unit
+5,A,Leader
+`
+	_, _, err := BuildPackFromCSV(strings.NewReader(src), CSVPackOpts{
+		Build:        PackBuildOpts{FormatID: 1},
+		SectionTypes: map[string]Section{"Unit": SectionDeck, "Leader": SectionLeader},
+	})
+	if !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("want ErrInvalidPack, got %v", err)
+	}
+	var lines []string
+	rows := err.(*PackError).Cause.(interface{ Unwrap() []error }).Unwrap()
+	if s := rows[0].Error(); !strings.HasPrefix(s, `deckcodec: line 3, column "pk": `) {
+		t.Fatalf("Error() = %q", s)
+	}
+	for _, e := range rows {
+		var re *CSVRowError
+		if !errors.As(e, &re) {
+			t.Fatalf("not a *CSVRowError: %v", e)
+		}
+		lines = append(lines, strconv.Itoa(re.Line)+":"+re.Column)
+	}
+	want := []string{"3:pk", "4:type", "5:", "7:type"}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("row errors at %v, want %v\n%v", lines, want, err)
+	}
+
+	for name, tc := range map[string]struct {
+		src  string
+		opts CSVPackOpts
+	}{
+		"no pk column":   {"id\n1\n", CSVPackOpts{}},
+		"no set column":  {"pk\n1\n", CSVPackOpts{Sets: []string{"A"}}},
+		"no type column": {"pk\n1\n", CSVPackOpts{Types: []string{"A"}}},
+		"empty":          {"", CSVPackOpts{}},
+		"no rows":        {"pk\n", CSVPackOpts{}},
+	} {
+		tc.opts.Build.FormatID = 1
+		if _, _, err := BuildPackFromCSV(strings.NewReader(tc.src), tc.opts); !errors.Is(err, ErrInvalidPack) {
+			t.Errorf("%s: want ErrInvalidPack, got %v", name, err)
+		}
+	}
+}
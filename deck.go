@@ -0,0 +1,70 @@
+package deckcodec
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// DeckEntry is one main deck card with its count.
+type DeckEntry struct {
+	PK    uint64
+	Count uint8
+}
+
+// SortedEntries returns the deck map as entries in ascending PK order,
+// a stable view for display, hashing or comparison.
+func SortedEntries(deck map[uint64]uint8) []DeckEntry {
+	out := make([]DeckEntry, 0, len(deck))
+	for pk, c := range deck {
+		out = append(out, DeckEntry{PK: pk, Count: c})
+	}
+	slices.SortFunc(out, func(a, b DeckEntry) int { return cmp.Compare(a.PK, b.PK) })
+	return out
+}
+
+// Entries returns the main deck in ascending PK order (see SortedEntries).
+func (in DeckInput) Entries() []DeckEntry { return SortedEntries(in.Deck) }
+
+// Entries returns the main deck in ascending PK order (see SortedEntries).
+func (out DeckOutput) Entries() []DeckEntry { return SortedEntries(out.Deck) }
+
+// Normalize returns a copy of in with leader and tactics sorted by PK, the
+// order Decode returns them in for sorted packs (append-only packs decode in
+// ordinal order). Repeated cards are kept, as Encode keeps them.
+// Like Encode, it does not check counts or PKs.
+func Normalize(in DeckInput) DeckInput {
+	out := DeckInput{
+		Leader:  slices.Clone(in.Leader),
+		Tactics: slices.Clone(in.Tactics),
+		Deck:    maps.Clone(in.Deck),
+	}
+	slices.Sort(out.Leader)
+	slices.Sort(out.Tactics)
+	return out
+}
+
+// Equal reports whether a and b are the same deck, equal as inputs (before
+// reprint folding). Section order does not matter, and nil sections equal
+// empty ones; repeated leader or tactics cards do. Decks that differ only in
+// untracked reprints (Pack.Reprints) encode alike; use EqualModReprints for those.
+func Equal(a, b DeckInput) bool {
+	if len(a.Leader) != len(b.Leader) || len(a.Tactics) != len(b.Tactics) || !maps.Equal(a.Deck, b.Deck) {
+		return false
+	}
+	return sortedEqual(a.Leader, b.Leader) && sortedEqual(a.Tactics, b.Tactics)
+}
+
+// sortedEqual reports whether a and b hold the same multiset of PKs.
+func sortedEqual(a, b []uint64) bool {
+	if slices.IsSorted(a) && slices.IsSorted(b) {
+		return slices.Equal(a, b)
+	}
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
+
+// ToInput returns the decoded deck as a DeckInput, e.g. to re-encode it with
+// another pack or compare it with Equal. The result does not share memory with out.
+func (out DeckOutput) ToInput() DeckInput {
+	return Normalize(DeckInput{Leader: out.Leader, Tactics: out.Tactics, Deck: out.Deck})
+}
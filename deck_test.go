@@ -0,0 +1,97 @@
+package deckcodec
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeAndEqual(t *testing.T) {
+	a := DeckInput{
+		Leader:  []uint64{1915, 101},
+		Tactics: []uint64{2117, 303, 303},
+		Deck:    map[uint64]uint8{205: 4, 1006: 1},
+	}
+	b := DeckInput{
+		Leader:  []uint64{101, 1915},
+		Tactics: []uint64{303, 2117, 303},
+		Deck:    map[uint64]uint8{1006: 1, 205: 4},
+	}
+	n := Normalize(a)
+	if !slices.Equal(n.Leader, []uint64{101, 1915}) || !slices.Equal(n.Tactics, []uint64{303, 303, 2117}) {
+		t.Fatalf("Normalize = %+v", n)
+	}
+	if a.Leader[0] != 1915 {
+		t.Fatal("Normalize modified its input")
+	}
+	n.Deck[205] = 1
+	if a.Deck[205] != 4 {
+		t.Fatal("Normalize shares the deck map")
+	}
+	if !Equal(a, b) || !Equal(a, Normalize(b)) {
+		t.Fatal("Equal(a, b) = false for reordered decks")
+	}
+	if !Equal(DeckInput{}, DeckInput{Leader: []uint64{}, Deck: map[uint64]uint8{}}) {
+		t.Fatal("nil and empty sections differ")
+	}
+
+	for name, c := range map[string]DeckInput{
+		"count":         {Leader: b.Leader, Tactics: b.Tactics, Deck: map[uint64]uint8{1006: 2, 205: 4}},
+		"repeat":        {Leader: b.Leader, Tactics: []uint64{303, 2117, 2117}, Deck: b.Deck},
+		"leader":        {Leader: []uint64{101}, Tactics: b.Tactics, Deck: b.Deck},
+		"moved section": {Leader: b.Leader, Tactics: []uint64{303, 303}, Deck: map[uint64]uint8{1006: 1, 205: 4, 2117: 1}},
+	} {
+		if Equal(a, c) {
+			t.Errorf("%s: Equal = true", name)
+		}
+	}
+}
+
+// TestEqual_MatchesEncode checks that Equal means "same code".
+func TestEqual_MatchesEncode(t *testing.T) {
+	p := testPack(5)
+	decks := []DeckInput{
+		{Leader: []uint64{101, 205}, Deck: map[uint64]uint8{303: 1}},
+		{Leader: []uint64{205, 101}, Deck: map[uint64]uint8{303: 1}},
+		{Leader: []uint64{205, 101}, Deck: map[uint64]uint8{303: 2}},
+		{Leader: []uint64{205, 205}, Deck: map[uint64]uint8{303: 1}},
+		{Tactics: []uint64{101, 205}, Deck: map[uint64]uint8{303: 1}},
+	}
+	for i, a := range decks {
+		ca, _ := Encode(p, a)
+		for j, b := range decks {
+			cb, _ := Encode(p, b)
+			if Equal(a, b) != (ca == cb) {
+				t.Errorf("decks %d, %d: Equal=%v, same code=%v", i, j, Equal(a, b), ca == cb)
+			}
+		}
+	}
+}
+
+func TestToInputAndEntries(t *testing.T) {
+	p := testPack(5)
+	in := DeckInput{Leader: []uint64{2117}, Tactics: []uint64{412, 101}, Deck: map[uint64]uint8{1915: 3, 205: 4, 602: 1}}
+	code, _ := Encode(p, in)
+	out, err := Decode(p, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := out.ToInput()
+	if !Equal(back, in) {
+		t.Fatalf("ToInput = %+v, want %+v", back, in)
+	}
+	back.Deck[205] = 1
+	back.Leader[0] = 1
+	if out.Deck[205] != 4 || out.Leader[0] != 2117 {
+		t.Fatal("ToInput shares memory with the DeckOutput")
+	}
+	want := []DeckEntry{{205, 4}, {602, 1}, {1915, 3}}
+	if got := out.Entries(); !slices.Equal(got, want) {
+		t.Fatalf("Entries = %v, want %v", got, want)
+	}
+	if got := in.Entries(); !slices.Equal(got, want) {
+		t.Fatalf("DeckInput.Entries = %v, want %v", got, want)
+	}
+	if got := SortedEntries(nil); len(got) != 0 {
+		t.Fatalf("SortedEntries(nil) = %v", got)
+	}
+}
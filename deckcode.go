@@ -0,0 +1,115 @@
+package deckcodec
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// DeckCode is a syntactically valid deck code: well-formed Base64URL with a
+// readable header. Whether it decodes against a pack is only known at Decode.
+//
+// The zero value is the empty code; it marshals to "" and unmarshals from "",
+// so DeckCode can be used directly for optional fields in JSON or config structs.
+// DeckCode values are comparable.
+type DeckCode struct {
+	s   string
+	fid uint16
+}
+
+// ParseDeckCode validates s and returns it as a DeckCode. It fails with
+// ErrInvalidCode or ErrTruncated if s is not Base64URL or too short for a header.
+// Line breaks, which Base64 decoding would skip, are rejected too, so equal
+// DeckCodes always hold the same text.
+func ParseDeckCode(s string) (DeckCode, error) {
+	if err := checkCodeChars(s); err != nil {
+		return DeckCode{}, err
+	}
+	fid, err := PeekFormatID(s)
+	if err != nil {
+		return DeckCode{}, err
+	}
+	return DeckCode{s: s, fid: fid}, nil
+}
+
+// MustParseDeckCode is ParseDeckCode that panics on error, for tests and constants.
+func MustParseDeckCode(s string) DeckCode {
+	c, err := ParseDeckCode(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// EncodeCode is Encode returning a DeckCode.
+func EncodeCode(p Pack, in DeckInput) (DeckCode, error) {
+	s, err := Encode(p, in)
+	if err != nil {
+		return DeckCode{}, err
+	}
+	return DeckCode{s: s, fid: p.FormatID}, nil
+}
+
+// String returns the code text ("" for the zero value).
+func (c DeckCode) String() string { return c.s }
+
+// FormatID returns the format ID from the code header (0 for the zero value).
+func (c DeckCode) FormatID() uint16 { return c.fid }
+
+// Len returns the length of the code in characters.
+func (c DeckCode) Len() int { return len(c.s) }
+
+// IsZero reports whether c is the zero (empty) code.
+func (c DeckCode) IsZero() bool { return c.s == "" }
+
+// Decode decodes c with pack p (see Decode).
+func (c DeckCode) Decode(p Pack) (DeckOutput, error) {
+	if c.IsZero() {
+		return DeckOutput{}, &TruncatedError{Section: SectionHeader, Offset: 0}
+	}
+	return Decode(p, c.s)
+}
+
+// DecodeWith decodes c with a precompiled Codec.
+func (c DeckCode) DecodeWith(cd *Codec) (DeckOutput, error) {
+	if c.IsZero() {
+		return DeckOutput{}, &TruncatedError{Section: SectionHeader, Offset: 0}
+	}
+	return cd.Decode(c.s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c DeckCode) MarshalText() ([]byte, error) {
+	return []byte(c.s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Empty text yields the zero DeckCode.
+func (c *DeckCode) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = DeckCode{}
+		return nil
+	}
+	v, err := ParseDeckCode(string(text))
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding c as a JSON string.
+func (c DeckCode) MarshalJSON() ([]byte, error) {
+	// Base64URL text never needs escaping.
+	return []byte(strconv.Quote(c.s)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. JSON null leaves c unchanged.
+func (c *DeckCode) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return c.UnmarshalText([]byte(s))
+}
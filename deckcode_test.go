@@ -0,0 +1,103 @@
+package deckcodec
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var (
+	_ encoding.TextMarshaler   = DeckCode{}
+	_ encoding.TextUnmarshaler = (*DeckCode)(nil)
+	_ json.Marshaler           = DeckCode{}
+	_ json.Unmarshaler         = (*DeckCode)(nil)
+	_ fmt.Stringer             = DeckCode{}
+)
+
+func TestDeckCode_ParseAndDecode(t *testing.T) {
+	p := testPack(77)
+	in := DeckInput{Leader: []uint64{101}, Deck: map[uint64]uint8{205: 3}}
+	c, err := EncodeCode(p, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := Encode(p, in)
+	if c.String() != s || c.Len() != len(s) || c.FormatID() != 77 || c.IsZero() {
+		t.Fatalf("EncodeCode = %+v, want %q", c, s)
+	}
+	parsed, err := ParseDeckCode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != c {
+		t.Fatalf("ParseDeckCode = %+v, want %+v", parsed, c)
+	}
+	out, err := parsed.Decode(p)
+	if err != nil || !equalDeckCounts(out.Deck, in.Deck) {
+		t.Fatalf("Decode = %+v, %v", out, err)
+	}
+	codec, _ := NewCodec(p)
+	if out, err := parsed.DecodeWith(codec); err != nil || !equalUint64Slices(out.Leader, in.Leader) {
+		t.Fatalf("DecodeWith = %+v, %v", out, err)
+	}
+
+	for _, bad := range []string{"", "!!!!", "AQ", "AAAAAA"} {
+		if _, err := ParseDeckCode(bad); err == nil {
+			t.Errorf("ParseDeckCode(%q) succeeded", bad)
+		}
+	}
+	for _, bad := range []string{s + "\n", s[:2] + "\r\n" + s[2:], "\r" + s, s + "="} {
+		if _, err := ParseDeckCode(bad); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("ParseDeckCode(%q) = %v, want ErrInvalidCode", bad, err)
+		}
+	}
+	if _, err := (DeckCode{}).Decode(p); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("zero Decode: %v", err)
+	}
+}
+
+func TestDeckCode_Marshaling(t *testing.T) {
+	p := testPack(3)
+	c, _ := EncodeCode(p, DeckInput{Tactics: []uint64{2117}})
+
+	type share struct {
+		Code     DeckCode  `json:"code"`
+		Optional DeckCode  `json:"optional"`
+		Ptr      *DeckCode `json:"ptr"`
+	}
+	b, err := json.Marshal(share{Code: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"code":"` + c.String() + `","optional":"","ptr":null}`
+	if string(b) != want {
+		t.Fatalf("Marshal = %s, want %s", b, want)
+	}
+	var got share
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != c || !got.Optional.IsZero() || got.Ptr != nil {
+		t.Fatalf("Unmarshal = %+v", got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"code":"not base64!"}`), &got); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("invalid code: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"code":12}`), &got); err == nil {
+		t.Fatal("number accepted as code")
+	}
+
+	var tc DeckCode
+	if err := tc.UnmarshalText([]byte(c.String())); err != nil || tc != c {
+		t.Fatalf("UnmarshalText = %+v, %v", tc, err)
+	}
+	if text, _ := tc.MarshalText(); string(text) != c.String() {
+		t.Fatalf("MarshalText = %s", text)
+	}
+	if fmt.Sprint(c) != c.String() {
+		t.Fatalf("Sprint = %s", fmt.Sprint(c))
+	}
+}
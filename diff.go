@@ -0,0 +1,273 @@
+package deckcodec
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CodeImpact classifies what a pack change means for existing deck codes.
+type CodeImpact uint8
+
+const (
+	// ImpactNone: same format ID and the same cards in the same order.
+	// Only metadata, retired cards or reprint classes changed; every code
+	// decodes as before.
+	ImpactNone CodeImpact = iota
+	// ImpactAppend: an append-only pack grew by appending cards. Old codes
+	// record their epoch and decode unchanged with the new pack.
+	ImpactAppend
+	// ImpactNewFormat: the packs have different format IDs. Old codes keep
+	// decoding with the old pack; decks using Removed cards cannot be encoded
+	// with the new one.
+	ImpactNewFormat
+	// ImpactBreaking: the same format ID with different ordinals or M. Old
+	// codes may fail or decode to the wrong cards; publish under a new format
+	// ID instead (a PackLock rejects this change).
+	ImpactBreaking
+)
+
+func (c CodeImpact) String() string {
+	switch c {
+	case ImpactNone:
+		return "none"
+	case ImpactAppend:
+		return "append"
+	case ImpactNewFormat:
+		return "new-format"
+	case ImpactBreaking:
+		return "breaking"
+	}
+	return "impact(" + strconv.Itoa(int(c)) + ")"
+}
+
+// impactNotes explains each impact in WriteText's report.
+var impactNotes = [...]string{
+	ImpactNone:      "only metadata changed; existing codes are unaffected",
+	ImpactAppend:    "cards appended; existing codes decode unchanged",
+	ImpactNewFormat: "new format_id; existing codes still need the old pack",
+	ImpactBreaking:  "same format_id with different ordinals; existing codes may decode wrongly",
+}
+
+// MarshalText encodes the impact by name in PackDiff's JSON output.
+func (c CodeImpact) MarshalText() ([]byte, error) { return []byte(c.String()), nil }
+
+// OrdinalShift is a card present in both packs whose ordinal changed.
+type OrdinalShift struct {
+	PK  uint64 `json:"pk"`
+	Old uint32 `json:"old"`
+	New uint32 `json:"new"`
+}
+
+// PackDiff describes the change from one pack to another; see DiffPacks.
+type PackDiff struct {
+	OldFormatID uint16 `json:"old_format_id"`
+	NewFormatID uint16 `json:"new_format_id"`
+	OldM        int    `json:"old_M"`
+	NewM        int    `json:"new_M"`
+	OldIDBits   int    `json:"old_id_bits"`
+	NewIDBits   int    `json:"new_id_bits"`
+	OldSHA256   string `json:"old_sha256"` // PackHash
+	NewSHA256   string `json:"new_sha256"`
+
+	Added   []uint64       `json:"added"`   // in new ordinal order
+	Removed []uint64       `json:"removed"` // in old ordinal order
+	Shifted []OrdinalShift `json:"shifted"` // in old ordinal order
+
+	// Retired lists the cards retired in new but not in old, and Unretired
+	// the cards of new that were retired in old but no longer are. Both are
+	// ascending. They change what Encode accepts, not what codes decode to.
+	Retired   []uint64 `json:"retired"`
+	Unretired []uint64 `json:"unretired"`
+
+	// ReprintsAdded lists, per canonical card, the printings new declares
+	// that old does not declare for the same card; ReprintsRemoved the
+	// reverse. A printing moved to another card appears in both. Both are
+	// ordered by canonical PK, with ascending printings.
+	ReprintsAdded   []ReprintClass `json:"reprints_added"`
+	ReprintsRemoved []ReprintClass `json:"reprints_removed"`
+
+	Impact CodeImpact `json:"impact"`
+}
+
+// DiffPacks compares oldPack and newPack by ordinal: the cards added and
+// removed, the cards whose ordinal moved, the ordinal width change and what
+// the change means for codes encoded against oldPack (Impact). It also lists
+// the cards retired and unretired by newPack and its reprint class changes,
+// which Impact does not cover.
+func DiffPacks(oldPack, newPack Pack) PackDiff {
+	oc, nc := lockCards(oldPack), lockCards(newPack)
+	d := PackDiff{
+		OldFormatID: oldPack.FormatID,
+		NewFormatID: newPack.FormatID,
+		OldM:        len(oc),
+		NewM:        len(nc),
+		OldIDBits:   idBits(len(oc)),
+		NewIDBits:   idBits(len(nc)),
+		OldSHA256:   PackHash(oldPack),
+		NewSHA256:   PackHash(newPack),
+		Added:       []uint64{},
+		Removed:     []uint64{},
+		Shifted:     []OrdinalShift{},
+		Retired:     pkDiff(newPack.Retired, oldPack.Retired),
+		Unretired:   pkDiff(oldPack.Retired, newPack.Retired),
+
+		ReprintsAdded:   reprintDiff(newPack.Reprints, oldPack.Reprints),
+		ReprintsRemoved: reprintDiff(oldPack.Reprints, newPack.Reprints),
+	}
+	// A retired card removed from newPack is reported as removed.
+	d.Unretired = slices.DeleteFunc(d.Unretired, func(pk uint64) bool { return !slices.Contains(nc, pk) })
+	newOrd := make(map[uint64]int, len(nc))
+	for i, pk := range nc {
+		if _, ok := newOrd[pk]; !ok {
+			newOrd[pk] = i
+		}
+	}
+	inOld := make(map[uint64]bool, len(oc))
+	for i, pk := range oc {
+		if inOld[pk] {
+			continue
+		}
+		inOld[pk] = true
+		j, ok := newOrd[pk]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, pk)
+		case j != i:
+			d.Shifted = append(d.Shifted, OrdinalShift{PK: pk, Old: uint32(i), New: uint32(j)})
+		}
+	}
+	for _, pk := range nc {
+		if !inOld[pk] {
+			d.Added = append(d.Added, pk)
+			inOld[pk] = true // report repeated PKs once
+		}
+	}
+
+	switch {
+	case oldPack.FormatID != newPack.FormatID:
+		d.Impact = ImpactNewFormat
+	case d.OldSHA256 == d.NewSHA256:
+		d.Impact = ImpactNone
+	case oldPack.AppendOnly && newPack.AppendOnly && len(d.Removed) == 0 && len(d.Shifted) == 0 && len(nc) >= len(oc):
+		d.Impact = ImpactAppend
+	default:
+		d.Impact = ImpactBreaking
+	}
+	return d
+}
+
+// pkDiff returns the PKs in a but not in b, ascending and without repeats.
+func pkDiff(a, b []uint64) []uint64 {
+	out := []uint64{}
+	for _, pk := range a {
+		if !slices.Contains(b, pk) {
+			out = append(out, pk)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// reprintDiff returns, per canonical card, the printings a declares and b
+// does not declare for the same card, ordered by canonical PK.
+func reprintDiff(a, b []ReprintClass) []ReprintClass {
+	out := []ReprintClass{}
+	for _, rc := range a {
+		var have []uint64
+		if i := slices.IndexFunc(b, func(o ReprintClass) bool { return o.Canonical == rc.Canonical }); i >= 0 {
+			have = b[i].Printings
+		}
+		if ps := pkDiff(rc.Printings, have); len(ps) > 0 {
+			out = append(out, ReprintClass{Canonical: rc.Canonical, Printings: ps})
+		}
+	}
+	slices.SortFunc(out, func(x, y ReprintClass) int { return cmp.Compare(x.Canonical, y.Canonical) })
+	return out
+}
+
+// WriteJSON writes the diff as indented JSON.
+func (d PackDiff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteText writes a human-readable report for release review. Ordinal shifts
+// are grouped into runs of consecutive old ordinals that moved by the same amount.
+func (d PackDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "format_id: %d -> %d\n", d.OldFormatID, d.NewFormatID)
+	fmt.Fprintf(&b, "cards:     %d -> %d (+%d added, -%d removed, %d shifted)\n", d.OldM, d.NewM, len(d.Added), len(d.Removed), len(d.Shifted))
+	fmt.Fprintf(&b, "id_bits:   %d -> %d\n", d.OldIDBits, d.NewIDBits)
+	fmt.Fprintf(&b, "sha256:    %s -> %s\n", d.OldSHA256, d.NewSHA256)
+	fmt.Fprintf(&b, "impact:    %s", d.Impact)
+	switch {
+	case d.Impact == ImpactNone && len(d.Retired)+len(d.Unretired)+len(d.ReprintsAdded)+len(d.ReprintsRemoved) > 0:
+		b.WriteString(" (retired cards or reprint classes changed; existing codes decode unchanged)")
+	case int(d.Impact) < len(impactNotes):
+		b.WriteString(" (" + impactNotes[d.Impact] + ")")
+	}
+	b.WriteString("\n")
+
+	pks := func(title string, list []uint64) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (%d):\n", title, len(list))
+		for i, pk := range list {
+			if i%10 == 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(" " + strconv.FormatUint(pk, 10))
+			if i%10 == 9 || i == len(list)-1 {
+				b.WriteString("\n")
+			}
+		}
+	}
+	pks("added", d.Added)
+	pks("removed", d.Removed)
+	pks("retired", d.Retired)
+	pks("unretired", d.Unretired)
+
+	reprints := func(title string, list []ReprintClass) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (%d):\n", title, len(list))
+		for _, rc := range list {
+			fmt.Fprintf(&b, "  canonical %d:", rc.Canonical)
+			for _, pk := range rc.Printings {
+				b.WriteString(" " + strconv.FormatUint(pk, 10))
+			}
+			b.WriteString("\n")
+		}
+	}
+	reprints("reprints added", d.ReprintsAdded)
+	reprints("reprints removed", d.ReprintsRemoved)
+
+	if len(d.Shifted) > 0 {
+		fmt.Fprintf(&b, "\nordinal shifts (%d):\n", len(d.Shifted))
+		for i := 0; i < len(d.Shifted); {
+			s := d.Shifted[i]
+			delta := int64(s.New) - int64(s.Old)
+			j := i + 1
+			for j < len(d.Shifted) && d.Shifted[j].Old == d.Shifted[j-1].Old+1 && int64(d.Shifted[j].New)-int64(d.Shifted[j].Old) == delta {
+				j++
+			}
+			e := d.Shifted[j-1]
+			if j-i == 1 {
+				fmt.Fprintf(&b, "  ordinal %d (pk %d): %+d\n", s.Old, s.PK, delta)
+			} else {
+				fmt.Fprintf(&b, "  ordinals %d..%d (pk %d..%d): %+d\n", s.Old, e.Old, s.PK, e.PK, delta)
+			}
+			i = j
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,186 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffPacks_Sorted(t *testing.T) {
+	oldPack := Pack{FormatID: 7, Cards: []uint64{3, 9, 10, 11, 12, 20, 30}}
+	newPack := Pack{FormatID: 8, Cards: []uint64{3, 5, 9, 10, 11, 12, 30, 40}}
+	d := DiffPacks(oldPack, newPack)
+
+	if !slices.Equal(d.Added, []uint64{5, 40}) || !slices.Equal(d.Removed, []uint64{20}) {
+		t.Fatalf("added %v removed %v", d.Added, d.Removed)
+	}
+	want := []OrdinalShift{{9, 1, 2}, {10, 2, 3}, {11, 3, 4}, {12, 4, 5}}
+	if !slices.Equal(d.Shifted, want) {
+		t.Fatalf("shifted %v, want %v", d.Shifted, want)
+	}
+	if d.OldM != 7 || d.NewM != 8 || d.OldIDBits != 3 || d.NewIDBits != 3 || d.Impact != ImpactNewFormat {
+		t.Fatalf("summary %+v", d)
+	}
+
+	// Reusing the format ID for the same change breaks old codes.
+	newPack.FormatID = 7
+	if d := DiffPacks(oldPack, newPack); d.Impact != ImpactBreaking {
+		t.Fatalf("impact %v, want breaking", d.Impact)
+	}
+	// Metadata-only change.
+	renamed := oldPack
+	renamed.Name = "renamed"
+	if d := DiffPacks(oldPack, renamed); d.Impact != ImpactNone || len(d.Added)+len(d.Removed)+len(d.Shifted) != 0 {
+		t.Fatalf("metadata change: %+v", d)
+	}
+}
+
+func TestDiffPacks_AppendOnly(t *testing.T) {
+	v1 := appendOnlyPack(t)
+	v2, err := AppendPack(v1, []uint64{50, 7000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := DiffPacks(v1, v2)
+	if d.Impact != ImpactAppend || !slices.Equal(d.Added, []uint64{50, 7000}) || len(d.Shifted) != 0 {
+		t.Fatalf("append diff: %+v", d)
+	}
+	// Moving a card in an append-only pack is breaking.
+	moved := v2
+	moved.Cards = slices.Clone(v2.Cards)
+	moved.Cards[0], moved.Cards[1] = moved.Cards[1], moved.Cards[0]
+	if d := DiffPacks(v1, moved); d.Impact != ImpactBreaking || len(d.Shifted) != 2 {
+		t.Fatalf("moved diff: %+v", d)
+	}
+}
+
+func TestPackDiff_Render(t *testing.T) {
+	d := DiffPacks(Pack{FormatID: 7, Cards: []uint64{3, 9, 10, 11, 12, 20}}, Pack{FormatID: 8, Cards: []uint64{1, 3, 9, 10, 11, 12}})
+
+	var text bytes.Buffer
+	if err := d.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"format_id: 7 -> 8\n",
+		"(+1 added, -1 removed, 5 shifted)",
+		"impact:    new-format (",
+		"added (1):\n  1\n",
+		"removed (1):\n  20\n",
+		"ordinals 0..4 (pk 3..12): +1\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := d.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["impact"] != "new-format" || got["old_id_bits"] != 3.0 || len(got["shifted"].([]any)) != 5 {
+		t.Fatalf("JSON report: %s", js.String())
+	}
+}
+
+// TestDiffPacks_Retired checks that retiring and unretiring cards is reported
+// even though codes and Impact are unaffected.
+func TestDiffPacks_Retired(t *testing.T) {
+	oldPack, err := RetireCards(Pack{FormatID: 7, Cards: []uint64{3, 9, 10, 11}}, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPack, err := RetireCards(Pack{FormatID: 7, Cards: oldPack.Cards}, 11, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := DiffPacks(oldPack, newPack)
+	if d.Impact != ImpactNone || !slices.Equal(d.Retired, []uint64{3, 11}) || !slices.Equal(d.Unretired, []uint64{9}) {
+		t.Fatalf("retire diff: %+v", d)
+	}
+
+	var text bytes.Buffer
+	if err := d.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"impact:    none (retired cards or reprint classes changed; existing codes decode unchanged)\n",
+		"retired (2):\n  3 11\n",
+		"unretired (1):\n  9\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report lacks %q:\n%s", want, text.String())
+		}
+	}
+	var js bytes.Buffer
+	if err := d.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var got struct{ Retired, Unretired []uint64 }
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil || !slices.Equal(got.Retired, d.Retired) || !slices.Equal(got.Unretired, d.Unretired) {
+		t.Fatalf("JSON report: %s", js.String())
+	}
+
+	// A retired card that is removed is reported as removed only.
+	dropped := Pack{FormatID: 8, Cards: []uint64{3, 10, 11}}
+	if d := DiffPacks(oldPack, dropped); len(d.Unretired) != 0 || !slices.Equal(d.Removed, []uint64{9}) {
+		t.Fatalf("removed retired card: %+v", d)
+	}
+}
+
+// TestDiffPacks_Reprints checks that reprint class changes are reported per
+// canonical card, including a printing that moves to another card.
+func TestDiffPacks_Reprints(t *testing.T) {
+	cards := []uint64{100, 200, 300}
+	oldPack := Pack{FormatID: 7, Cards: cards, Reprints: []ReprintClass{
+		{Canonical: 100, Printings: []uint64{900, 950}},
+		{Canonical: 300, Printings: []uint64{930}},
+	}}
+	newPack := Pack{FormatID: 7, Cards: cards, Reprints: []ReprintClass{
+		{Canonical: 200, Printings: []uint64{920, 950}},
+		{Canonical: 100, Printings: []uint64{900, 910}},
+		{Canonical: 300, Printings: []uint64{930}},
+	}}
+	d := DiffPacks(oldPack, newPack)
+	wantAdded := []ReprintClass{{Canonical: 100, Printings: []uint64{910}}, {Canonical: 200, Printings: []uint64{920, 950}}}
+	wantRemoved := []ReprintClass{{Canonical: 100, Printings: []uint64{950}}}
+	eq := func(a, b []ReprintClass) bool {
+		return slices.EqualFunc(a, b, func(x, y ReprintClass) bool {
+			return x.Canonical == y.Canonical && slices.Equal(x.Printings, y.Printings)
+		})
+	}
+	if d.Impact != ImpactNone || !eq(d.ReprintsAdded, wantAdded) || !eq(d.ReprintsRemoved, wantRemoved) {
+		t.Fatalf("reprint diff: %+v", d)
+	}
+
+	var text bytes.Buffer
+	if err := d.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"impact:    none (retired cards or reprint classes changed;",
+		"reprints added (2):\n  canonical 100: 910\n  canonical 200: 920 950\n",
+		"reprints removed (1):\n  canonical 100: 950\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report lacks %q:\n%s", want, text.String())
+		}
+	}
+	var js bytes.Buffer
+	if err := d.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Added   []ReprintClass `json:"reprints_added"`
+		Removed []ReprintClass `json:"reprints_removed"`
+	}
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil || !eq(got.Added, wantAdded) || !eq(got.Removed, wantRemoved) {
+		t.Fatalf("JSON report: %s", js.String())
+	}
+}
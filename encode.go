@@ -1,12 +1,13 @@
 package deckcodec
 
 import (
+	"cmp"
 	"encoding/base64"
-	"errors"
+	"fmt"
 	"slices"
-	"sort"
+	"sync"
 
-	bitio "github.com/Argonauts-inc/deckcodec/internal"
+	"github.com/Argonauts-inc/deckcodec/bitstream"
 )
 
 type DeckInput struct {
@@ -20,6 +21,44 @@ type DeckOutput struct {
 	Leader   []uint64
 	Tactics  []uint64
 	Deck     map[uint64]uint8
+	Fixes    CodeFix // input normalizations applied in lenient mode (zero otherwise)
+	// Retired lists the retired cards (Pack.Retired) in the deck, ascending
+	// and without repeats; empty if there are none.
+	Retired []uint64
+}
+
+// EncodeOpts controls optional parts of the code written by EncodeWithOpts.
+type EncodeOpts struct {
+	// Fingerprint adds the 16-bit PackFingerprint to the header. For a sorted
+	// pack it also switches to the extended header, 40 more bits in all (an
+	// empty deck grows from 7 to 14 characters); an append-only pack already
+	// has it and grows by 16 bits. Decode then fails with ErrPackMismatch
+	// instead of returning wrong cards when given a stale, edited or corrupted pack.
+	// DecodeOpts.Fingerprint is its counterpart for strict decoding.
+	Fingerprint bool
+	// AllowRetired lets Encode use retired cards (Pack.Retired), e.g. to
+	// re-encode an existing deck. Without it they fail with ErrRetiredCard.
+	AllowRetired bool
+}
+
+// DecodeOpts controls how DecodeWithOpts treats its input.
+type DecodeOpts struct {
+	// Lenient normalizes the code with NormalizeCode before decoding and
+	// records the applied fixes in DeckOutput.Fixes.
+	Lenient bool
+	// Strict rejects any code that Encode would not have produced byte-for-byte:
+	// line breaks or other characters outside the Base64URL alphabet, a
+	// non-canonical header, unsorted or duplicate ordinals, non-zero padding
+	// bits and trailing bytes.
+	// Use it when codes serve as database or cache keys. For append-only packs
+	// codes are canonical within one epoch: a code from before cards were
+	// appended stays valid next to the one Encode writes now.
+	Strict bool
+	// Fingerprint is whether strict mode expects the pack fingerprint in the
+	// header, as with the EncodeOpts.Fingerprint the codes were written with.
+	// A deck has one code with the fingerprint and one without, so strict mode
+	// rejects the other. Non-strict decoding accepts both.
+	Fingerprint bool
 }
 
 // idBits returns the minimum number of bits required to represent m distinct values.
@@ -35,34 +74,244 @@ func idBits(m int) int {
 	return b
 }
 
+// dict is the pack state the encoder and decoder work against. The free
+// functions build one per call; Codec builds one once, with a hash index.
+type dict struct {
+	fid    uint16
+	cards  []uint64
+	ib     int               // idBits(len(cards))
+	index  map[uint64]uint32 // PK → ordinal; nil means search cards (see lookup)
+	byPK   []uint32          // Pack.byPK for an append-only pack; nil means scan cards
+	epoch  bool              // append-only pack: codes record their epoch M
+	fps    []uint16          // fps[m-1] is the fingerprint of cards[:m]; nil means compute on demand
+	withFP bool              // Encode writes the pack fingerprint (EncodeOpts.Fingerprint)
+
+	reprints []ReprintClass    // Pack.Reprints
+	canon    map[uint64]uint64 // printing → canonical PK; nil means scan reprints
+
+	retired      []uint64        // Pack.Retired
+	retiredSet   map[uint64]bool // set of retired; nil means scan retired
+	allowRetired bool            // Encode accepts retired cards (EncodeOpts.AllowRetired)
+}
+
+// fingerprint returns the fingerprint of the first m cards.
+func (d *dict) fingerprint(m int) uint16 {
+	if d.fps != nil {
+		return d.fps[m-1]
+	}
+	return fingerprint(d.fid, d.cards[:m])
+}
+
+// packDict returns a dict for p, without a map index: lookups binary-search a
+// sorted pack, and an append-only one through the PK order its constructor
+// built, which for deck-sized inputs is cheaper than building a map per call
+// (a Codec builds one once).
+func packDict(p Pack) dict {
+	d := dict{fid: p.FormatID, cards: p.Cards, ib: idBits(len(p.Cards)), epoch: p.AppendOnly,
+		reprints: p.Reprints, retired: p.Retired}
+	if p.AppendOnly && len(p.byPK) == len(p.Cards) {
+		d.byPK = p.byPK
+	}
+	return d
+}
+
+// header returns the header Encode writes for d.
+func (d *dict) header() header {
+	h := header{fid: d.fid, m: len(d.cards), epoch: d.epoch}
+	if d.withFP {
+		h.hasFP, h.fp = true, d.fingerprint(len(d.cards))
+	}
+	return h
+}
+
+// ordinal returns the ordinal of pk, and false if pk is not in the pack.
+// An untracked reprint (see ReprintClass) has the ordinal of its canonical card.
+func (d *dict) ordinal(pk uint64) (uint32, bool) {
+	o, ok := d.lookup(pk)
+	if ok || len(d.reprints) == 0 {
+		return o, ok
+	}
+	if d.canon != nil {
+		if c, isPrinting := d.canon[pk]; isPrinting {
+			return d.lookup(c)
+		}
+		return o, false
+	}
+	for _, rc := range d.reprints {
+		if slices.Contains(rc.Printings, pk) {
+			return d.lookup(rc.Canonical)
+		}
+	}
+	return o, false
+}
+
+// isRetired reports whether Encode must reject the card at ordinal o.
+func (d *dict) isRetired(o uint32) bool {
+	return !d.allowRetired && d.retiredPK(d.cards[o])
+}
+
+// retiredPK reports whether pk is in Pack.Retired.
+func (d *dict) retiredPK(pk uint64) bool {
+	if len(d.retired) == 0 {
+		return false
+	}
+	if d.retiredSet != nil {
+		return d.retiredSet[pk]
+	}
+	return slices.Contains(d.retired, pk)
+}
+
+// lookup returns the ordinal of pk in the card list. Without an index it
+// binary-searches a sorted pack, and an append-only one through byPK if set;
+// otherwise it scans.
+func (d *dict) lookup(pk uint64) (uint32, bool) {
+	if d.index != nil {
+		o, ok := d.index[pk]
+		return o, ok
+	}
+	if !d.epoch {
+		return ordinalOf(d.cards, pk)
+	}
+	if d.byPK != nil {
+		i, ok := slices.BinarySearchFunc(d.byPK, pk, func(o uint32, t uint64) int { return cmp.Compare(d.cards[o], t) })
+		if !ok {
+			return 0, false
+		}
+		return d.byPK[i], true
+	}
+	i := slices.Index(d.cards, pk)
+	return uint32(i), i >= 0
+}
+
 // ordinalOf returns the index (ordinal) of pk in the sorted cards slice, and true if found.
 // If pk is not found, returns the index where it would be inserted and false.
 func ordinalOf(cards []uint64, pk uint64) (uint32, bool) {
-	i := sort.Search(len(cards), func(i int) bool { return cards[i] >= pk })
-	return uint32(i), i < len(cards) && cards[i] == pk
+	i, ok := slices.BinarySearch(cards, pk)
+	return uint32(i), ok
 }
 
+// pair is a deck entry (ordinal, count) as written to the code.
+type pair struct {
+	o uint32
+	c uint8
+}
+
+// scratch holds per-call buffers so steady-state encode/decode does not allocate.
+type scratch struct {
+	L, T []uint32 // leader / tactics ordinals
+	P    []pair   // deck entries
+	raw  []byte   // bit stream
+	code []byte   // Base64URL text
+}
+
+var scratchPool = sync.Pool{New: func() any { return new(scratch) }}
+
 // Encode encodes a deck (DeckInput) into a compact base64 string using the provided Pack definition.
 // The encoding includes the format ID, leader cards, tactics cards, and the main deck with counts.
 // Returns the encoded string or an error if the input is invalid.
 func Encode(p Pack, in DeckInput) (string, error) {
 	// Check for valid pack format and card list
+	if err := checkEncodePack(p); err != nil {
+		return "", err
+	}
+	d := packDict(p)
+	return d.encodeString(in)
+}
+
+// EncodeWithOpts is Encode with explicit options (see EncodeOpts).
+func EncodeWithOpts(p Pack, in DeckInput, opts EncodeOpts) (string, error) {
+	if err := checkEncodePack(p); err != nil {
+		return "", err
+	}
+	d := packDict(p)
+	d.withFP = opts.Fingerprint
+	d.allowRetired = opts.AllowRetired
+	return d.encodeString(in)
+}
+
+// AppendEncode is Encode that appends the code to dst instead of returning a string.
+// With a dst of sufficient capacity it does not allocate.
+func AppendEncode(dst []byte, p Pack, in DeckInput) ([]byte, error) {
+	if err := checkEncodePack(p); err != nil {
+		return dst, err
+	}
+	d := packDict(p)
+	return d.appendEncode(dst, in)
+}
+
+// EncodeBytes is Encode without the Base64URL step: it returns the raw bit stream.
+func EncodeBytes(p Pack, in DeckInput) ([]byte, error) {
+	if err := checkEncodePack(p); err != nil {
+		return nil, err
+	}
+	d := packDict(p)
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	return d.appendRaw(nil, in, sc)
+}
+
+// checkEncodePack validates the pack fields Encode relies on. Reprints and
+// retired cards are validated once by BuildPack, ParsePack and NewCodec, not
+// on every call.
+func checkEncodePack(p Pack) error {
 	if p.FormatID == 0 {
-		return "", errors.New("deckcodec: pack.FormatID must be non-zero")
+		return &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
 	}
 	if len(p.Cards) == 0 {
-		return "", errors.New("deckcodec: empty pack")
+		return &PackError{FormatID: p.FormatID, Reason: "empty pack", Err: ErrInvalidPack}
+	}
+	return nil
+}
+
+// encodeString encodes a deck to a Base64URL string.
+func (d *dict) encodeString(in DeckInput) (string, error) {
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	raw, err := d.appendRaw(sc.raw[:0], in, sc)
+	if err != nil {
+		return "", err
+	}
+	sc.raw = raw
+	// Encode as base64 (URL-safe, no padding)
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// appendEncode appends the Base64URL code of a deck to dst.
+func (d *dict) appendEncode(dst []byte, in DeckInput) ([]byte, error) {
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	raw, err := d.appendRaw(sc.raw[:0], in, sc)
+	if err != nil {
+		return dst, err
 	}
-	// Calculate the number of bits needed to represent a card ordinal
-	ib := idBits(len(p.Cards))
+	sc.raw = raw
+	return base64.RawURLEncoding.AppendEncode(dst, raw), nil
+}
 
+// appendRaw appends the bit stream of a deck (before Base64URL encoding) to dst,
+// using sc for the intermediate ordinal lists.
+func (d *dict) appendRaw(dst []byte, in DeckInput, sc *scratch) ([]byte, error) {
+	if err := d.toOrdinals(in, sc); err != nil {
+		return dst, err
+	}
+	return appendSections(dst, d.header(), sc.L, sc.T, sc.P), nil
+}
+
+// toOrdinals maps a deck to sorted ordinal sections in sc.L, sc.T and sc.P.
+func (d *dict) toOrdinals(in DeckInput, sc *scratch) error {
 	// Helper function to convert a slice of card PKs to their ordinals in the pack
-	toOrd := func(pks []uint64) ([]uint32, error) {
-		out := make([]uint32, 0, len(pks))
+	toOrd := func(out []uint32, pks []uint64, sec Section) ([]uint32, error) {
+		if len(pks) > 255 {
+			return out, &SectionTooLongError{Section: sec, Len: len(pks)}
+		}
+		out = out[:0]
 		for _, pk := range pks {
-			o, ok := ordinalOf(p.Cards, pk)
+			o, ok := d.ordinal(pk)
 			if !ok {
-				return nil, errors.New("deckcodec: pk not in pack")
+				return out, &UnknownCardError{PK: pk, Section: sec}
+			}
+			if d.isRetired(o) {
+				return out, &RetiredCardError{PK: pk, Section: sec}
 			}
 			out = append(out, o)
 		}
@@ -71,160 +320,340 @@ func Encode(p Pack, in DeckInput) (string, error) {
 		return out, nil
 	}
 	// Convert leader and tactics PKs to ordinals
-	L, err := toOrd(in.Leader)
+	var err error
+	sc.L, err = toOrd(sc.L, in.Leader, SectionLeader)
 	if err != nil {
-		return "", err
+		return err
 	}
-	T, err := toOrd(in.Tactics)
+	sc.T, err = toOrd(sc.T, in.Tactics, SectionTactics)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	// Prepare the main deck as a slice of (ordinal, count) pairs
-	type pair struct {
-		o uint32
-		c uint8
+	if len(in.Deck) > 255 {
+		return &SectionTooLongError{Section: SectionDeck, Len: len(in.Deck)}
 	}
-	P := make([]pair, 0, len(in.Deck))
+	P := sc.P[:0]
 	for pk, c := range in.Deck {
 		// Only allow card counts between 1 and 4
 		if c < 1 || c > 4 {
-			return "", errors.New("deckcodec: count out of range (1..4)")
+			return &CountRangeError{PK: pk, Count: c}
 		}
-		o, ok := ordinalOf(p.Cards, pk)
+		o, ok := d.ordinal(pk)
 		if !ok {
-			return "", errors.New("deckcodec: pk not in pack")
+			return &UnknownCardError{PK: pk, Section: SectionDeck}
+		}
+		if d.isRetired(o) {
+			return &RetiredCardError{PK: pk, Section: SectionDeck}
 		}
 		P = append(P, pair{o: o, c: c})
 	}
 	// Sort deck pairs by ordinal for deterministic encoding
-	sort.Slice(P, func(i, j int) bool { return P[i].o < P[j].o })
+	slices.SortFunc(P, func(a, b pair) int { return cmp.Compare(a.o, b.o) })
+	if len(d.reprints) > 0 {
+		// Untracked reprints share their canonical card's ordinal: add up counts.
+		n := 0
+		for _, pr := range P {
+			if n > 0 && P[n-1].o == pr.o {
+				if P[n-1].c += pr.c; P[n-1].c > 4 {
+					return &CountRangeError{PK: d.cards[pr.o], Count: P[n-1].c}
+				}
+				continue
+			}
+			P[n] = pr
+			n++
+		}
+		P = P[:n]
+	}
+	sc.P = P
+	return nil
+}
 
-	var bw bitio.Writer
-	// Write header: 16 bits for format ID
-	bw.WriteBits(uint32(p.FormatID), 16)
+// appendSections appends the bit stream for already validated, sorted sections to dst.
+func appendSections(dst []byte, h header, L, T []uint32, P []pair) []byte {
+	ib := idBits(h.m)
+	bw := bitstream.Writer{Buf: dst}
+	// Write header: 16 bits for format ID, or the extended header
+	h.write(&bw)
 
 	// Write leader section: 8 bits for count, then each ordinal
-	if len(L) > 255 {
-		return "", errors.New("deckcodec: leader too long")
-	}
-	bw.WriteBits(uint32(len(L)), 8)
+	bw.WriteBits(uint64(len(L)), 8)
 	for _, o := range L {
-		bw.WriteBits(o, ib)
+		bw.WriteBits(uint64(o), ib)
 	}
 
 	// Write tactics section: 8 bits for count, then each ordinal
-	if len(T) > 255 {
-		return "", errors.New("deckcodec: tactics too long")
-	}
-	bw.WriteBits(uint32(len(T)), 8)
+	bw.WriteBits(uint64(len(T)), 8)
 	for _, o := range T {
-		bw.WriteBits(o, ib)
+		bw.WriteBits(uint64(o), ib)
 	}
 
 	// Write deck section: 8 bits for unique card count, then each (ordinal, count-1) pair
-	if len(P) > 255 {
-		return "", errors.New("deckcodec: deck unique too long")
-	}
-	bw.WriteBits(uint32(len(P)), 8)
+	bw.WriteBits(uint64(len(P)), 8)
 	for _, pr := range P {
-		bw.WriteBits(pr.o, ib)          // Write card ordinal
-		bw.WriteBits(uint32(pr.c-1), 2) // Write count minus 1 (so 1..4 becomes 0..3)
+		bw.WriteBits(uint64(pr.o), ib)  // Write card ordinal
+		bw.WriteBits(uint64(pr.c-1), 2) // Write count minus 1 (so 1..4 becomes 0..3)
 	}
 
-	// Finalize bit stream and encode as base64 (URL-safe, no padding)
-	raw := bw.Finish()
-	return base64.RawURLEncoding.EncodeToString(raw), nil
+	// Finalize bit stream
+	return bw.Finish()
 }
 
 // Decode decodes a base64-encoded deck string into a DeckOutput using the provided Pack definition.
 // Returns the decoded deck or an error if the code is invalid or does not match the pack.
 func Decode(p Pack, code string) (DeckOutput, error) {
-	// Decode base64 string to raw bytes
-	raw, err := base64.RawURLEncoding.DecodeString(code)
-	if err != nil {
+	return DecodeWithOpts(p, code, DecodeOpts{})
+}
+
+// DecodeInto is Decode that writes into out, reusing its Leader/Tactics slices
+// and Deck map. Once out has grown to the deck's size it does not allocate.
+// On error the contents of out are unspecified.
+func DecodeInto(p Pack, code string, out *DeckOutput) error {
+	d := packDict(p)
+	return d.decodeStringInto(code, DecodeOpts{}, out)
+}
+
+// DecodeBytes is Decode for a raw bit stream, as returned by EncodeBytes.
+func DecodeBytes(p Pack, raw []byte) (DeckOutput, error) {
+	d := packDict(p)
+	return d.decode(raw, DecodeOpts{}, nil)
+}
+
+// DecodeWithOpts is Decode with explicit options (see DecodeOpts).
+func DecodeWithOpts(p Pack, code string, opts DecodeOpts) (DeckOutput, error) {
+	d := packDict(p)
+	return d.decodeString(code, opts)
+}
+
+// decodeString decodes a Base64URL code according to opts.
+func (d *dict) decodeString(code string, opts DecodeOpts) (DeckOutput, error) {
+	var out DeckOutput
+	if err := d.decodeStringInto(code, opts, &out); err != nil {
 		return DeckOutput{}, err
 	}
-	// Calculate the number of bits needed to represent a card ordinal
-	ib := idBits(len(p.Cards))
+	return out, nil
+}
 
-	// Initialize bit reader
-	br := bitio.NewReader(raw, len(raw)*8)
-	// Read and check format ID (16 bits)
-	fid, err := br.ReadBits(16)
+// decodeStringInto decodes a Base64URL code according to opts into out.
+func (d *dict) decodeStringInto(code string, opts DecodeOpts, out *DeckOutput) error {
+	var fixes CodeFix
+	if opts.Lenient {
+		code, fixes = NormalizeCode(code)
+	}
+	// Decode base64 string to raw bytes (strict mode also rejects
+	// non-zero bits in the final character and line breaks)
+	enc := base64.RawURLEncoding
+	if opts.Strict {
+		if err := checkCodeChars(code); err != nil {
+			return err
+		}
+		enc = enc.Strict()
+	}
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	sc.code = append(sc.code[:0], code...)
+	sc.raw = slices.Grow(sc.raw[:0], enc.DecodedLen(len(sc.code)))
+	n, err := enc.Decode(sc.raw[:cap(sc.raw)], sc.code)
 	if err != nil {
-		return DeckOutput{}, err
+		return fmt.Errorf("%w: %w", ErrInvalidCode, err)
 	}
-	if uint16(fid) != p.FormatID {
-		return DeckOutput{}, errors.New("deckcodec: format_id mismatch")
+	if err := d.decodeInto(sc.raw[:n], opts, nil, out); err != nil {
+		return err
 	}
+	out.Fixes = fixes
+	return nil
+}
 
-	// Helper function to convert an ordinal to a PK (card ID)
-	toPK := func(o uint32) (uint64, error) {
-		if int(o) >= len(p.Cards) {
-			return 0, errors.New("deckcodec: ordinal OOB")
+// checkCodeChars reports the first byte of code outside the Base64URL
+// alphabet. encoding/base64 skips '\r' and '\n' even in strict mode, so a
+// code with line breaks would otherwise decode like the code without them.
+func checkCodeChars(code string) error {
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if !('A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("%w: %w", ErrInvalidCode, base64.CorruptInputError(i))
 		}
-		return p.Cards[o], nil
 	}
+	return nil
+}
 
-	// Read leader section: 8 bits for count, then each ordinal
-	nL, err := br.ReadBits(8)
-	if err != nil {
+// decode decodes the bit stream of a code into a new DeckOutput.
+func (d *dict) decode(raw []byte, opts DecodeOpts, trace func(Field)) (DeckOutput, error) {
+	var out DeckOutput
+	if err := d.decodeInto(raw, opts, trace, &out); err != nil {
 		return DeckOutput{}, err
 	}
-	L := make([]uint64, nL)
-	for i := 0; i < int(nL); i++ {
-		o, err := br.ReadBits(ib)
+	return out, nil
+}
+
+// decodeInto decodes the bit stream of a code (after Base64URL decoding) into out,
+// reusing its slices and map.
+// If opts.Strict is set, it also enforces the canonical form produced by Encode
+// (with opts.Fingerprint as EncodeOpts.Fingerprint); opts.Lenient is ignored.
+// If trace is non-nil, it is called for every field read (see Explain).
+func (d *dict) decodeInto(raw []byte, opts DecodeOpts, trace func(Field), out *DeckOutput) error {
+	strict := opts.Strict
+	// Initialize bit reader
+	br := bitstream.NewReader(raw, len(raw)*8)
+
+	// Read and check the header
+	h, err := readHeader(&br, trace)
+	if err != nil {
+		return err
+	}
+	if h.fid != d.fid {
+		return &FormatMismatchError{Code: h.fid, Pack: d.fid}
+	}
+	// Ordinals index into the first m cards. A code for an append-only pack
+	// records the m it was encoded against, which stays valid as the pack grows.
+	// Append-only ordinals follow insertion order and sorted ones PK order, so
+	// a code decodes only with a pack of the same kind: an epoch for a sorted
+	// pack, or none for an append-only pack (whose M may have changed since),
+	// would silently read the wrong cards.
+	m, ib := len(d.cards), d.ib
+	if (h.epoch != 0) != d.epoch || h.epoch > m {
+		return &EpochError{FormatID: d.fid, Code: h.epoch, Pack: m, AppendOnly: d.epoch}
+	}
+	if h.epoch != 0 {
+		m, ib = h.epoch, idBits(h.epoch)
+	}
+	if h.hasFP() {
+		if fp := d.fingerprint(m); h.fp != fp {
+			return &FingerprintError{FormatID: d.fid, Code: h.fp, Pack: fp}
+		}
+	}
+	if strict {
+		// Encode writes the extended header exactly when it has a flag to set,
+		// and the fingerprint exactly when asked to.
+		switch {
+		case h.version != 0 && h.flags == 0:
+			return &NonCanonicalError{Section: SectionHeader, Offset: 0, Reason: "extended header without flags"}
+		case h.hasFP() && !opts.Fingerprint:
+			return &NonCanonicalError{Section: SectionHeader, Offset: 0, Reason: "unexpected fingerprint"}
+		case !h.hasFP() && opts.Fingerprint:
+			return &NonCanonicalError{Section: SectionHeader, Offset: 0, Reason: "missing fingerprint"}
+		}
+	}
+
+	// Helper function to read a field, reporting truncation with its bit offset.
+	// Ordinal fields are traced by readPK once the PK is resolved.
+	read := func(width int, sec Section, idx int, name string) (uint64, error) {
+		off := br.Offset()
+		v, err := br.ReadBits(width)
 		if err != nil {
-			return DeckOutput{}, err
+			return 0, &TruncatedError{Section: sec, Offset: off}
+		}
+		if trace != nil && name != "ordinal" {
+			trace(Field{Section: sec, Index: idx, Name: name, Offset: off, Width: width, Raw: v})
 		}
-		pk, err := toPK(o)
+		return v, nil
+	}
+	// Helper function to read an ordinal and convert it to a PK (card ID).
+	// In strict mode, ordinals must ascend (strictly for the deck section).
+	// Retired cards are collected in R.
+	var prev uint32
+	R := out.Retired[:0]
+	readPK := func(i int, sec Section) (uint64, error) {
+		off := br.Offset()
+		v, err := read(ib, sec, i, "ordinal")
 		if err != nil {
-			return DeckOutput{}, err
+			return 0, err
+		}
+		o := uint32(v)
+		if trace != nil {
+			f := Field{Section: sec, Index: i, Name: "ordinal", Offset: off, Width: ib, Raw: uint64(o)}
+			if int(o) < m {
+				f.PK, f.HasPK = d.cards[o], true
+			}
+			trace(f)
+		}
+		if strict && i > 0 && (o < prev || (sec == SectionDeck && o == prev)) {
+			return 0, &NonCanonicalError{Section: sec, Offset: off, Reason: "unsorted or duplicate ordinals"}
+		}
+		prev = o
+		if int(o) >= m {
+			return 0, &OrdinalRangeError{Section: sec, Offset: off, Ordinal: o, M: m}
 		}
-		L[i] = pk
+		pk := d.cards[o]
+		if d.retiredPK(pk) {
+			R = append(R, pk)
+		}
+		return pk, nil
 	}
 
-	// Read tactics section: 8 bits for count, then each ordinal
-	nT, err := br.ReadBits(8)
+	// Read leader section: 8 bits for count, then each ordinal
+	nL, err := read(8, SectionLeader, -1, "length")
 	if err != nil {
-		return DeckOutput{}, err
+		return err
 	}
-	T := make([]uint64, nT)
-	for i := 0; i < int(nT); i++ {
-		o, err := br.ReadBits(ib)
-		if err != nil {
-			return DeckOutput{}, err
+	L := resize(out.Leader, int(nL))
+	for i := range L {
+		if L[i], err = readPK(i, SectionLeader); err != nil {
+			return err
 		}
-		pk, err := toPK(o)
-		if err != nil {
-			return DeckOutput{}, err
+	}
+
+	// Read tactics section: 8 bits for count, then each ordinal
+	nT, err := read(8, SectionTactics, -1, "length")
+	if err != nil {
+		return err
+	}
+	T := resize(out.Tactics, int(nT))
+	for i := range T {
+		if T[i], err = readPK(i, SectionTactics); err != nil {
+			return err
 		}
-		T[i] = pk
 	}
 
 	// Read deck section: 8 bits for unique card count, then each (ordinal, count-1) pair
-	nD, err := br.ReadBits(8)
+	nD, err := read(8, SectionDeck, -1, "length")
 	if err != nil {
-		return DeckOutput{}, err
+		return err
+	}
+	D := out.Deck
+	if D == nil {
+		D = make(map[uint64]uint8, nD)
+	} else {
+		clear(D)
 	}
-	D := make(map[uint64]uint8, nD)
 	for i := 0; i < int(nD); i++ {
-		o, err := br.ReadBits(ib)
-		if err != nil {
-			return DeckOutput{}, err
-		}
-		cm1, err := br.ReadBits(2)
+		pk, err := readPK(i, SectionDeck)
 		if err != nil {
-			return DeckOutput{}, err
+			return err
 		}
-		pk, err := toPK(o)
+		cm1, err := read(2, SectionDeck, i, "count")
 		if err != nil {
-			return DeckOutput{}, err
+			return err
 		}
 		D[pk] = uint8(cm1) + 1 // Convert stored count-1 back to count (1..4)
 	}
 
-	// Return the decoded deck structure
-	return DeckOutput{FormatID: p.FormatID, Leader: L, Tactics: T, Deck: D}, nil
+	if strict {
+		// The stream must end in the last byte, with zero padding bits.
+		used := br.Offset()
+		if len(raw) != (used+7)/8 {
+			return &NonCanonicalError{Section: SectionDeck, Offset: used, Reason: "trailing bytes"}
+		}
+		if pad := used % 8; pad != 0 && raw[len(raw)-1]>>pad != 0 {
+			return &NonCanonicalError{Section: SectionDeck, Offset: used, Reason: "non-zero padding bits"}
+		}
+	}
+
+	if len(R) > 1 {
+		slices.Sort(R)
+		R = slices.Compact(R)
+	}
+
+	// Store the decoded deck structure
+	*out = DeckOutput{FormatID: d.fid, Leader: L, Tactics: T, Deck: D, Retired: R}
+	return nil
+}
+
+// resize returns s with length n, reusing its backing array when large enough.
+func resize(s []uint64, n int) []uint64 {
+	if cap(s) < n {
+		return make([]uint64, n)
+	}
+	return s[:n]
 }
@@ -2,10 +2,15 @@
 package deckcodec
 
 import (
+	"encoding/base64"
+	"errors"
 	"regexp"
 	"slices"
 	"sort"
+	"strings"
 	"testing"
+
+	"github.com/Argonauts-inc/deckcodec/bitstream"
 )
 
 // testPack returns a small, ascending card dictionary with the given format ID.
@@ -265,3 +270,257 @@ func TestLengthMonotonicity(t *testing.T) {
 	}
 	_ = sort.Ints // keep import if needed later
 }
+
+// rawCode hand-assembles a code for testPack (M=26, id_bits=5) from section
+// ordinals, bypassing Encode's normalization. Deck entries are (ordinal, count).
+func rawCode(fid uint16, leader, tactics []uint32, deck [][2]uint32) []byte {
+	const ib = 5
+	var bw bitstream.Writer
+	bw.WriteBits(uint64(fid), 16)
+	bw.WriteBits(uint64(len(leader)), 8)
+	for _, o := range leader {
+		bw.WriteBits(uint64(o), ib)
+	}
+	bw.WriteBits(uint64(len(tactics)), 8)
+	for _, o := range tactics {
+		bw.WriteBits(uint64(o), ib)
+	}
+	bw.WriteBits(uint64(len(deck)), 8)
+	for _, e := range deck {
+		bw.WriteBits(uint64(e[0]), ib)
+		bw.WriteBits(uint64(e[1]-1), 2)
+	}
+	return bw.Finish()
+}
+
+// TestDecodeStrict_AcceptsCanonical ensures strict mode accepts everything Encode emits.
+func TestDecodeStrict_AcceptsCanonical(t *testing.T) {
+	p := testPack(1)
+	for _, in := range []DeckInput{
+		{Leader: []uint64{412, 101}, Tactics: []uint64{705, 301}, Deck: map[uint64]uint8{501: 4, 2117: 1}},
+		{Leader: []uint64{101, 101}, Deck: map[uint64]uint8{501: 1}}, // duplicate leaders are encodable
+		{},
+	} {
+		code, err := Encode(p, in)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if _, err := DecodeWithOpts(p, code, DecodeOpts{Strict: true}); err != nil {
+			t.Fatalf("strict decode rejected canonical code %q: %v", code, err)
+		}
+	}
+}
+
+// TestDecodeStrict_RejectsNonCanonical builds codes that the lax decoder accepts
+// but Encode would never produce, and checks strict mode rejects each of them.
+func TestDecodeStrict_RejectsNonCanonical(t *testing.T) {
+	p := testPack(1)
+	canonical := rawCode(1, []uint32{0, 1}, []uint32{2}, [][2]uint32{{3, 4}, {5, 1}})
+
+	withPadding := slices.Clone(canonical)
+	withPadding[len(withPadding)-1] |= 0x80
+
+	cases := map[string][]byte{
+		"unsorted leader":     rawCode(1, []uint32{1, 0}, []uint32{2}, [][2]uint32{{3, 4}, {5, 1}}),
+		"unsorted tactics":    rawCode(1, []uint32{0, 1}, []uint32{4, 2}, [][2]uint32{{3, 4}, {5, 1}}),
+		"unsorted deck":       rawCode(1, []uint32{0, 1}, []uint32{2}, [][2]uint32{{5, 1}, {3, 4}}),
+		"duplicate deck":      rawCode(1, []uint32{0, 1}, []uint32{2}, [][2]uint32{{3, 4}, {3, 1}}),
+		"non-zero padding":    withPadding,
+		"trailing byte":       append(slices.Clone(canonical), 0),
+		"trailing zero bytes": append(slices.Clone(canonical), 0, 0, 0),
+	}
+
+	if _, err := DecodeWithOpts(p, base64.RawURLEncoding.EncodeToString(canonical), DecodeOpts{Strict: true}); err != nil {
+		t.Fatalf("hand-built canonical code rejected: %v", err)
+	}
+	for name, raw := range cases {
+		code := base64.RawURLEncoding.EncodeToString(raw)
+		if _, err := Decode(p, code); err != nil {
+			t.Fatalf("%s: lax decode should accept, got %v", name, err)
+		}
+		if _, err := DecodeWithOpts(p, code, DecodeOpts{Strict: true}); !errors.Is(err, ErrNotCanonical) {
+			t.Fatalf("%s: expected ErrNotCanonical, got %v", name, err)
+		}
+	}
+
+	// Text aliases: encoding/base64 skips line breaks.
+	code := base64.RawURLEncoding.EncodeToString(canonical)
+	c, err := NewCodec(p)
+	if err != nil {
+		t.Fatalf("NewCodec failed: %v", err)
+	}
+	for _, alias := range []string{code + "\n", code[:2] + "\r\n" + code[2:], "\r" + code} {
+		if _, err := Decode(p, alias); err != nil {
+			t.Fatalf("%q: lax decode should accept, got %v", alias, err)
+		}
+		if _, err := DecodeWithOpts(p, alias, DecodeOpts{Strict: true}); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("%q: expected ErrInvalidCode, got %v", alias, err)
+		}
+		if _, err := c.DecodeWithOpts(alias, DecodeOpts{Strict: true}); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("%q: Codec: expected ErrInvalidCode, got %v", alias, err)
+		}
+	}
+
+	// Header alias of an empty deck: an extended header with no flags
+	// (canonical is the legacy header).
+	var noFlags bitstream.Writer
+	noFlags.WriteBits(0, 16)
+	noFlags.WriteBits(extVersion, 4)
+	noFlags.WriteBits(uint64(p.FormatID), 16)
+	noFlags.WriteBits(0, 4)
+	noFlags.WriteBits(0, 24)
+	code = base64.RawURLEncoding.EncodeToString(noFlags.Finish())
+	if _, err := Decode(p, code); err != nil {
+		t.Fatalf("extended header without flags: lax decode should accept, got %v", err)
+	}
+	if _, err := DecodeWithOpts(p, code, DecodeOpts{Strict: true}); !errors.Is(err, ErrNotCanonical) {
+		t.Fatalf("extended header without flags: expected ErrNotCanonical, got %v", err)
+	}
+}
+
+// TestDecodeStrict_Base64TrailingBits checks that strict mode rejects a final
+// Base64 character carrying non-zero unused bits ("AQ" vs "AR" decode alike).
+func TestDecodeStrict_Base64TrailingBits(t *testing.T) {
+	p := testPack(1)
+	code, err := Encode(p, DeckInput{})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(code)
+	if len(raw)%3 == 0 {
+		t.Fatalf("need a code whose last character has unused bits, got %d bytes", len(raw))
+	}
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	last := strings.IndexByte(alphabet, code[len(code)-1])
+	alias := code[:len(code)-1] + string(alphabet[last+1])
+	if _, err := Decode(p, alias); err != nil {
+		t.Fatalf("lax decode should accept alias %q: %v", alias, err)
+	}
+	if _, err := DecodeWithOpts(p, alias, DecodeOpts{Strict: true}); err == nil {
+		t.Fatalf("strict decode accepted alias %q of %q", alias, code)
+	}
+}
+
+// TestAppendEncodeAndBytes checks that the append and raw-bytes variants agree with Encode.
+func TestAppendEncodeAndBytes(t *testing.T) {
+	p := testPack(1)
+	in := DeckInput{
+		Leader:  []uint64{412, 205},
+		Tactics: []uint64{705, 301},
+		Deck:    map[uint64]uint8{501: 4, 602: 3, 703: 2, 804: 1},
+	}
+	code, err := Encode(p, in)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	got, err := AppendEncode([]byte("/deck/"), p, in)
+	if err != nil || string(got) != "/deck/"+code {
+		t.Fatalf("AppendEncode: got %q, %v", got, err)
+	}
+
+	raw, err := EncodeBytes(p, in)
+	if err != nil {
+		t.Fatalf("EncodeBytes failed: %v", err)
+	}
+	if base64.RawURLEncoding.EncodeToString(raw) != code {
+		t.Fatalf("EncodeBytes is not the raw form of Encode")
+	}
+	out, err := DecodeBytes(p, raw)
+	if err != nil || !equalDeckCounts(out.Deck, in.Deck) || len(out.Leader) != 2 {
+		t.Fatalf("DecodeBytes: got %+v, %v", out, err)
+	}
+
+	// Errors leave dst untouched.
+	dst := []byte("x")
+	if got, err := AppendEncode(dst, p, DeckInput{Leader: []uint64{9}}); err == nil || string(got) != "x" {
+		t.Fatalf("AppendEncode error path: got %q, %v", got, err)
+	}
+}
+
+// TestDecodeInto_Reuse decodes a large then a small deck into the same output.
+func TestDecodeInto_Reuse(t *testing.T) {
+	p := testPack(1)
+	big, _ := Encode(p, DeckInput{
+		Leader:  []uint64{101, 205, 303, 412},
+		Tactics: []uint64{301, 402, 503},
+		Deck:    map[uint64]uint8{501: 4, 602: 3, 703: 2, 804: 1},
+	})
+	small, _ := Encode(p, DeckInput{Leader: []uint64{412}, Deck: map[uint64]uint8{905: 2}})
+
+	var out DeckOutput
+	if err := DecodeInto(p, big, &out); err != nil {
+		t.Fatalf("DecodeInto(big) failed: %v", err)
+	}
+	deck := out.Deck
+	if err := DecodeInto(p, small, &out); err != nil {
+		t.Fatalf("DecodeInto(small) failed: %v", err)
+	}
+	if !slices.Equal(out.Leader, []uint64{412}) || len(out.Tactics) != 0 {
+		t.Fatalf("sections not reset: %+v", out)
+	}
+	if !equalDeckCounts(out.Deck, map[uint64]uint8{905: 2}) {
+		t.Fatalf("deck not reset: %v", out.Deck)
+	}
+	out.Deck[1] = 1
+	if deck[1] != 1 {
+		t.Fatalf("DecodeInto did not reuse the caller's map")
+	}
+}
+
+// TestZeroAllocs checks the steady-state allocation counts of the append/into APIs.
+func TestZeroAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool is not deterministic under -race")
+	}
+	p := testPack(1)
+	c, err := NewCodec(p)
+	if err != nil {
+		t.Fatalf("NewCodec failed: %v", err)
+	}
+	in := DeckInput{
+		Leader:  []uint64{412, 205, 101, 303},
+		Tactics: []uint64{705, 402, 604, 503, 301},
+		Deck:    map[uint64]uint8{501: 4, 602: 3, 703: 2, 804: 1},
+	}
+	code, _ := Encode(p, in)
+	dst := make([]byte, 0, 64)
+	var out DeckOutput
+
+	// Append-only packs are in insertion order; the free functions search
+	// them without building a PK index.
+	ao := appendOnlyPack(t)
+	aoIn := DeckInput{Leader: []uint64{500}, Deck: map[uint64]uint8{900: 2, 300: 1}}
+	aoCode, err := Encode(ao, aoIn)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	// Nor does either direction need a printing → canonical map or a set of
+	// retired cards.
+	rp, err := RetireCards(reprintPack(t), 200)
+	if err != nil {
+		t.Fatalf("RetireCards failed: %v", err)
+	}
+	rpIn := DeckInput{Leader: []uint64{900}, Deck: map[uint64]uint8{100: 1, 900: 2, 950: 1, 300: 4}}
+	rpCode, err := EncodeWithOpts(rp, DeckInput{Leader: []uint64{900, 200}, Deck: rpIn.Deck}, EncodeOpts{AllowRetired: true})
+	if err != nil {
+		t.Fatalf("EncodeWithOpts failed: %v", err)
+	}
+
+	for name, fn := range map[string]func(){
+		"AppendEncode/Reprints":   func() { dst, _ = AppendEncode(dst[:0], rp, rpIn) },
+		"DecodeInto/Reprints":     func() { _ = DecodeInto(rp, rpCode, &out) },
+		"AppendEncode":            func() { dst, _ = AppendEncode(dst[:0], p, in) },
+		"Codec.AppendEncode":      func() { dst, _ = c.AppendEncode(dst[:0], in) },
+		"DecodeInto":              func() { _ = DecodeInto(p, code, &out) },
+		"Codec.DecodeInto":        func() { _ = c.DecodeInto(code, &out) },
+		"DecodeInto/AppendOnly":   func() { _ = DecodeInto(ao, aoCode, &out) },
+		"AppendEncode/AppendOnly": func() { dst, _ = AppendEncode(dst[:0], ao, aoIn) },
+	} {
+		fn() // warm up pools and output buffers
+		if n := testing.AllocsPerRun(100, fn); n != 0 {
+			t.Fatalf("%s: %v allocs/op, want 0", name, n)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package deckcodec
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Sentinel errors. Every error returned by Encode, Decode, ParsePack and
+// BuildManifest matches one of these with errors.Is; the typed errors below
+// carry the details (PK, section, bit offset, ...) for errors.As.
+var (
+	ErrInvalidPack       = errors.New("deckcodec: invalid pack")
+	ErrUnknownCard       = errors.New("deckcodec: pk not in pack")
+	ErrCountRange        = errors.New("deckcodec: count out of range (1..4)")
+	ErrSectionTooLong    = errors.New("deckcodec: section too long")
+	ErrInvalidCode       = errors.New("deckcodec: invalid code")
+	ErrFormatMismatch    = errors.New("deckcodec: format_id mismatch")
+	ErrTruncated         = errors.New("deckcodec: code truncated")
+	ErrOrdinalRange      = errors.New("deckcodec: ordinal out of range")
+	ErrNotCanonical      = errors.New("deckcodec: code is not canonical")
+	ErrDuplicateFormatID = errors.New("deckcodec: duplicate format_id")
+	ErrMissingURL        = errors.New("deckcodec: urlFor returned empty URL")
+	ErrPackMismatch      = errors.New("deckcodec: pack content mismatch")
+	ErrRetiredCard       = errors.New("deckcodec: card is retired")
+)
+
+// Section identifies a part of the code layout.
+type Section uint8
+
+const (
+	SectionHeader Section = iota
+	SectionLeader
+	SectionTactics
+	SectionDeck
+)
+
+func (s Section) String() string {
+	switch s {
+	case SectionHeader:
+		return "header"
+	case SectionLeader:
+		return "leader"
+	case SectionTactics:
+		return "tactics"
+	case SectionDeck:
+		return "deck"
+	}
+	return "section(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText encodes the section by name (e.g. in Explain's JSON output).
+func (s Section) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// UnknownCardError reports a PK that is not part of the pack.
+type UnknownCardError struct {
+	PK      uint64
+	Section Section
+}
+
+func (e *UnknownCardError) Error() string {
+	return "deckcodec: pk " + strconv.FormatUint(e.PK, 10) + " (" + e.Section.String() + ") not in pack"
+}
+
+func (e *UnknownCardError) Unwrap() error { return ErrUnknownCard }
+
+// RetiredCardError reports a retired PK (Pack.Retired) given to Encode
+// without EncodeOpts.AllowRetired.
+type RetiredCardError struct {
+	PK      uint64
+	Section Section
+}
+
+func (e *RetiredCardError) Error() string {
+	return "deckcodec: pk " + strconv.FormatUint(e.PK, 10) + " (" + e.Section.String() + ") is retired"
+}
+
+func (e *RetiredCardError) Unwrap() error { return ErrRetiredCard }
+
+// CountRangeError reports a deck count outside 1..4.
+// Encode reports the entry by PK; EncodeOrdinals, which has no PKs, reports
+// its Ordinal and the bit Offset of its count field instead (0 from Encode).
+type CountRangeError struct {
+	PK      uint64
+	Count   uint8
+	Ordinal uint32
+	Offset  int
+}
+
+func (e *CountRangeError) Error() string {
+	if e.Offset > 0 {
+		return "deckcodec: count " + strconv.Itoa(int(e.Count)) + " for ordinal " +
+			strconv.FormatUint(uint64(e.Ordinal), 10) + " at bit " + strconv.Itoa(e.Offset) + " out of range (1..4)"
+	}
+	return "deckcodec: count " + strconv.Itoa(int(e.Count)) + " for pk " +
+		strconv.FormatUint(e.PK, 10) + " out of range (1..4)"
+}
+
+func (e *CountRangeError) Unwrap() error { return ErrCountRange }
+
+// SectionTooLongError reports a section with more entries than its 8-bit length field allows.
+type SectionTooLongError struct {
+	Section Section
+	Len     int
+}
+
+func (e *SectionTooLongError) Error() string {
+	return "deckcodec: " + e.Section.String() + " too long (" + strconv.Itoa(e.Len) + " > 255)"
+}
+
+func (e *SectionTooLongError) Unwrap() error { return ErrSectionTooLong }
+
+// FormatMismatchError reports a code whose format_id differs from the pack's.
+type FormatMismatchError struct {
+	Code uint16 // format_id found in the code
+	Pack uint16 // format_id of the pack used to decode
+}
+
+func (e *FormatMismatchError) Error() string {
+	return "deckcodec: format_id mismatch (code " + strconv.Itoa(int(e.Code)) +
+		", pack " + strconv.Itoa(int(e.Pack)) + ")"
+}
+
+func (e *FormatMismatchError) Unwrap() error { return ErrFormatMismatch }
+
+// TruncatedError reports a code that ended before a field could be read.
+// Offset is the bit offset of the field that could not be read.
+type TruncatedError struct {
+	Section Section
+	Offset  int
+}
+
+func (e *TruncatedError) Error() string {
+	return "deckcodec: code truncated in " + e.Section.String() + " at bit " + strconv.Itoa(e.Offset)
+}
+
+func (e *TruncatedError) Unwrap() error { return ErrTruncated }
+
+// EpochError reports a code whose recorded pack size (epoch) cannot be decoded
+// with the given pack: the pack is append-only and older than the code or the
+// code records no epoch, or the pack is sorted and the code records one.
+type EpochError struct {
+	FormatID   uint16
+	Code       int  // pack size recorded in the code, 0 if absent
+	Pack       int  // size of the pack used to decode
+	AppendOnly bool // the pack used to decode is append-only
+}
+
+func (e *EpochError) Error() string {
+	var msg string
+	switch {
+	case e.AppendOnly && e.Code == 0:
+		msg = "code without epoch cannot be decoded with append-only pack of M=" + strconv.Itoa(e.Pack)
+	case !e.AppendOnly:
+		msg = "code for append-only pack of M=" + strconv.Itoa(e.Code) + " cannot be decoded with sorted pack of M=" + strconv.Itoa(e.Pack)
+	default:
+		msg = "code for M=" + strconv.Itoa(e.Code) + " cannot be decoded with pack of M=" + strconv.Itoa(e.Pack)
+	}
+	return "deckcodec: " + msg + " (format_id " + strconv.Itoa(int(e.FormatID)) + ")"
+}
+
+func (e *EpochError) Unwrap() error { return ErrFormatMismatch }
+
+// FingerprintError reports a code whose pack fingerprint differs from the
+// fingerprint of the pack used to decode: the pack has the right format ID
+// but different content (stale cache, local edits or corruption).
+type FingerprintError struct {
+	FormatID uint16
+	Code     uint16 // fingerprint recorded in the code
+	Pack     uint16 // fingerprint of the pack used to decode
+}
+
+func (e *FingerprintError) Error() string {
+	return "deckcodec: pack content mismatch (format_id " + strconv.Itoa(int(e.FormatID)) +
+		", code fingerprint " + strconv.FormatUint(uint64(e.Code), 16) + ", pack " + strconv.FormatUint(uint64(e.Pack), 16) + ")"
+}
+
+func (e *FingerprintError) Unwrap() error { return ErrPackMismatch }
+
+// OrdinalRangeError reports an ordinal that does not index into the pack.
+type OrdinalRangeError struct {
+	Section Section
+	Offset  int    // bit offset of the ordinal field
+	Ordinal uint32 // value read from the code
+	M       int    // pack size
+}
+
+func (e *OrdinalRangeError) Error() string {
+	return "deckcodec: ordinal " + strconv.FormatUint(uint64(e.Ordinal), 10) + " in " + e.Section.String() +
+		" at bit " + strconv.Itoa(e.Offset) + " out of range (M=" + strconv.Itoa(e.M) + ")"
+}
+
+func (e *OrdinalRangeError) Unwrap() error { return ErrOrdinalRange }
+
+// NonCanonicalError reports why a code was rejected in strict mode.
+type NonCanonicalError struct {
+	Section Section
+	Offset  int    // bit offset where the deviation starts
+	Reason  string // e.g. "unsorted ordinals", "trailing bytes"
+}
+
+func (e *NonCanonicalError) Error() string {
+	return "deckcodec: code is not canonical: " + e.Reason + " in " + e.Section.String() +
+		" at bit " + strconv.Itoa(e.Offset)
+}
+
+func (e *NonCanonicalError) Unwrap() error { return ErrNotCanonical }
+
+// PackError reports a problem with a specific pack. Err is one of the sentinel
+// errors (ErrInvalidPack, ErrDuplicateFormatID, ErrMissingURL or ErrPackLocked);
+// Cause, if set, is the underlying error (e.g. from encoding/json).
+type PackError struct {
+	FormatID uint16
+	Reason   string
+	Err      error
+	Cause    error
+}
+
+func (e *PackError) Error() string {
+	msg := e.Err.Error()
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	if e.FormatID != 0 {
+		msg += " (format_id " + strconv.Itoa(int(e.FormatID)) + ")"
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *PackError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.Cause}
+}
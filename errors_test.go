@@ -0,0 +1,114 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEncode_TypedErrors checks that Encode errors carry the offending PK and section.
+func TestEncode_TypedErrors(t *testing.T) {
+	p := testPack(1)
+
+	_, err := Encode(p, DeckInput{Tactics: []uint64{301, 999}})
+	var uce *UnknownCardError
+	if !errors.Is(err, ErrUnknownCard) || !errors.As(err, &uce) {
+		t.Fatalf("expected UnknownCardError, got %v", err)
+	}
+	if uce.PK != 999 || uce.Section != SectionTactics {
+		t.Fatalf("unexpected details: %+v", uce)
+	}
+
+	_, err = Encode(p, DeckInput{Deck: map[uint64]uint8{501: 5}})
+	var cre *CountRangeError
+	if !errors.Is(err, ErrCountRange) || !errors.As(err, &cre) || cre.PK != 501 || cre.Count != 5 {
+		t.Fatalf("expected CountRangeError{501,5}, got %v", err)
+	}
+
+	_, err = Encode(p, DeckInput{Leader: make([]uint64, 256)})
+	var stl *SectionTooLongError
+	if !errors.Is(err, ErrSectionTooLong) || !errors.As(err, &stl) || stl.Section != SectionLeader || stl.Len != 256 {
+		t.Fatalf("expected SectionTooLongError for leader, got %v", err)
+	}
+
+	if _, err := Encode(Pack{Cards: p.Cards}, DeckInput{}); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("expected ErrInvalidPack for zero FormatID, got %v", err)
+	}
+	if _, err := Encode(Pack{FormatID: 1}, DeckInput{}); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("expected ErrInvalidPack for empty pack, got %v", err)
+	}
+}
+
+// TestDecode_TypedErrors checks format mismatch, truncation offsets, ordinal range and bad Base64.
+func TestDecode_TypedErrors(t *testing.T) {
+	p := testPack(1)
+	code, err := Encode(p, DeckInput{Leader: []uint64{101, 205}, Deck: map[uint64]uint8{501: 2}})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	_, err = Decode(testPack(9), code)
+	var fme *FormatMismatchError
+	if !errors.Is(err, ErrFormatMismatch) || !errors.As(err, &fme) || fme.Code != 1 || fme.Pack != 9 {
+		t.Fatalf("expected FormatMismatchError{1,9}, got %v", err)
+	}
+
+	// Cut after the two leader ordinals (16+8+2*5 = 34 bits → 4 bytes keeps 32 bits).
+	raw, _ := base64.RawURLEncoding.DecodeString(code)
+	short := base64.RawURLEncoding.EncodeToString(raw[:4])
+	_, err = Decode(p, short)
+	var te *TruncatedError
+	if !errors.Is(err, ErrTruncated) || !errors.As(err, &te) {
+		t.Fatalf("expected TruncatedError, got %v", err)
+	}
+	if te.Section != SectionLeader || te.Offset != 16+8+5 {
+		t.Fatalf("unexpected truncation details: %+v", te)
+	}
+
+	// Ordinal 31 does not exist in a 26-card pack.
+	bad := base64.RawURLEncoding.EncodeToString(rawCode(1, nil, nil, [][2]uint32{{31, 1}}))
+	_, err = Decode(p, bad)
+	var ore *OrdinalRangeError
+	if !errors.Is(err, ErrOrdinalRange) || !errors.As(err, &ore) {
+		t.Fatalf("expected OrdinalRangeError, got %v", err)
+	}
+	if ore.Ordinal != 31 || ore.M != 26 || ore.Section != SectionDeck || ore.Offset != 40 {
+		t.Fatalf("unexpected ordinal details: %+v", ore)
+	}
+
+	if _, err := Decode(p, "!!!"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected ErrInvalidCode, got %v", err)
+	}
+	var cie base64.CorruptInputError
+	if _, err := Decode(p, "!!!"); !errors.As(err, &cie) {
+		t.Fatalf("expected underlying base64 error, got %v", err)
+	}
+}
+
+// TestPackErrors_Typed checks ParsePack, BuildPack and BuildManifest error matching.
+func TestPackErrors_Typed(t *testing.T) {
+	if _, err := ParsePack(strings.NewReader(`{"format_id":0,"cards":[1]}`)); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("ParsePack zero fid: got %v", err)
+	}
+	_, err := ParsePack(bytes.NewBufferString(`{"format_id":1,"cards":[1],"oops":true}`))
+	var pe *PackError
+	if !errors.Is(err, ErrInvalidPack) || !errors.As(err, &pe) || pe.Cause == nil {
+		t.Fatalf("ParsePack unknown field: expected PackError with cause, got %v", err)
+	}
+	if _, err := BuildPack(nil, PackBuildOpts{FormatID: 3}); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("BuildPack empty: got %v", err)
+	}
+
+	urlOK := func(uint16) string { return "u" }
+	_, err = BuildManifest([]Pack{mkPack(7, "A", []uint64{1}), mkPack(7, "B", []uint64{2})}, urlOK, 1, time.Now(), 0)
+	if !errors.Is(err, ErrDuplicateFormatID) || !errors.As(err, &pe) || pe.FormatID != 7 {
+		t.Fatalf("BuildManifest duplicate: got %v", err)
+	}
+	_, err = BuildManifest([]Pack{mkPack(4, "A", []uint64{1})}, func(uint16) string { return "" }, 1, time.Now(), 0)
+	if !errors.Is(err, ErrMissingURL) {
+		t.Fatalf("BuildManifest empty URL: got %v", err)
+	}
+}
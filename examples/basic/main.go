@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
 	"time"
 
@@ -136,18 +135,9 @@ func main() {
 	// Pretty print decoded deck
 	prettyPrint(out)
 
-	// Verify normalized sections + counts
-	wantL := slices.Clone(in.Leader)
-	wantT := slices.Clone(in.Tactics)
-	slices.Sort(wantL)
-	slices.Sort(wantT)
-	if !slices.Equal(wantL, out.Leader) || !slices.Equal(wantT, out.Tactics) {
-		log.Fatalf("normalized mismatch (leaders/tactics)")
-	}
-	for pk, c := range in.Deck {
-		if out.Deck[pk] != c {
-			log.Fatalf("count mismatch on pk=%d: got=%d want=%d", pk, out.Deck[pk], c)
-		}
+	// Verify sections + counts (order-insensitive, as Encode normalizes)
+	if !deckcodec.Equal(in, out.ToInput()) {
+		log.Fatalf("round-trip mismatch: got %+v want %+v", out.ToInput(), deckcodec.Normalize(in))
 	}
 	fmt.Println("OK: encode/decode round-trip verified")
 }
@@ -0,0 +1,103 @@
+package deckcodec
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Field is one bit field of a code, as read by Decode.
+type Field struct {
+	Section Section `json:"section"`
+	Index   int     `json:"index"` // entry index within the section, -1 for section-level fields
+	Name    string  `json:"name"`  // "format_id", "escape", "version", "flags", "epoch", "fingerprint", "length", "ordinal" or "count"
+	Offset  int     `json:"offset"`
+	Width   int     `json:"width"`
+	Raw     uint64  `json:"raw"`
+	PK      uint64  `json:"pk,omitempty"`
+	HasPK   bool    `json:"has_pk,omitempty"` // PK is set (ordinal fields within the pack)
+}
+
+// Label returns a readable field path such as "format_id", "leader.length" or "deck[3].count".
+func (f Field) Label() string {
+	if f.Section == SectionHeader {
+		return f.Name
+	}
+	if f.Index < 0 {
+		return f.Section.String() + "." + f.Name
+	}
+	return f.Section.String() + "[" + strconv.Itoa(f.Index) + "]." + f.Name
+}
+
+// Explanation is an annotated, bit-level breakdown of a code.
+type Explanation struct {
+	FormatID      uint16  `json:"format_id"` // format ID of the pack used
+	M             int     `json:"m"`         // pack size the ordinals index into (the code's epoch, if recorded)
+	IDBits        int     `json:"id_bits"`
+	TotalBits     int     `json:"total_bits"` // len(raw bytes) * 8
+	Fields        []Field `json:"fields"`
+	PaddingStart  int     `json:"padding_start"`  // bit offset right after the last field
+	PaddingBits   int     `json:"padding_bits"`   // bits up to the next byte boundary
+	TrailingBytes int     `json:"trailing_bytes"` // whole bytes after the padding
+}
+
+// Explain walks a code exactly like Decode and records every field it reads:
+// name, bit offset, width, raw value and (for ordinals) the resolved PK.
+// On a decode error it returns the fields read so far together with the error,
+// so the explanation shows where the code went wrong.
+func Explain(p Pack, code string) (Explanation, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return Explanation{}, fmt.Errorf("%w: %w", ErrInvalidCode, err)
+	}
+	e := Explanation{
+		FormatID:  p.FormatID,
+		M:         len(p.Cards),
+		IDBits:    idBits(len(p.Cards)),
+		TotalBits: len(raw) * 8,
+	}
+	d := packDict(p)
+	_, err = d.decode(raw, DecodeOpts{}, func(f Field) {
+		if f.Name == "epoch" {
+			// Ordinals index into the pack as of the recorded epoch.
+			e.M, e.IDBits = int(f.Raw), idBits(int(f.Raw))
+		}
+		e.Fields = append(e.Fields, f)
+	})
+	if n := len(e.Fields); n > 0 {
+		e.PaddingStart = e.Fields[n-1].Offset + e.Fields[n-1].Width
+	}
+	used := (e.PaddingStart + 7) / 8
+	e.PaddingBits = used*8 - e.PaddingStart
+	e.TrailingBytes = len(raw) - used
+	return e, err
+}
+
+// WriteText renders the explanation as an aligned table.
+func (e Explanation) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "format_id=%d M=%d id_bits=%d total_bits=%d\n", e.FormatID, e.M, e.IDBits, e.TotalBits)
+	fmt.Fprintln(tw, "offset\twidth\tfield\traw\tpk")
+	for _, f := range e.Fields {
+		if f.HasPK {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%d\n", f.Offset, f.Width, f.Label(), f.Raw, f.PK)
+		} else {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%d\n", f.Offset, f.Width, f.Label(), f.Raw)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "padding: %d bits from bit %d\ntrailing bytes: %d\n", e.PaddingBits, e.PaddingStart, e.TrailingBytes)
+	return err
+}
+
+// WriteJSON renders the explanation as indented JSON.
+func (e Explanation) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
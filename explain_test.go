@@ -0,0 +1,120 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestExplain_Fields checks offsets, widths, raw values and resolved PKs.
+func TestExplain_Fields(t *testing.T) {
+	p := testPack(1)
+	code, err := Encode(p, DeckInput{
+		Leader:  []uint64{205, 101},
+		Tactics: []uint64{301},
+		Deck:    map[uint64]uint8{501: 4, 602: 1},
+	})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	e, err := Explain(p, code)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	type want struct {
+		label      string
+		off, width int
+		raw        uint64
+		pk         uint64
+		hasPK      bool
+	}
+	wants := []want{
+		{"format_id", 0, 16, 1, 0, false},
+		{"leader.length", 16, 8, 2, 0, false},
+		{"leader[0].ordinal", 24, 5, 0, 101, true},
+		{"leader[1].ordinal", 29, 5, 1, 205, true},
+		{"tactics.length", 34, 8, 1, 0, false},
+		{"tactics[0].ordinal", 42, 5, 2, 301, true},
+		{"deck.length", 47, 8, 2, 0, false},
+		{"deck[0].ordinal", 55, 5, 6, 501, true},
+		{"deck[0].count", 60, 2, 3, 0, false},
+		{"deck[1].ordinal", 62, 5, 8, 602, true},
+		{"deck[1].count", 67, 2, 0, 0, false},
+	}
+	if len(e.Fields) != len(wants) {
+		t.Fatalf("got %d fields, want %d", len(e.Fields), len(wants))
+	}
+	for i, w := range wants {
+		f := e.Fields[i]
+		if f.Label() != w.label || f.Offset != w.off || f.Width != w.width || f.Raw != w.raw || f.PK != w.pk || f.HasPK != w.hasPK {
+			t.Fatalf("field %d: got %+v (%s), want %+v", i, f, f.Label(), w)
+		}
+	}
+	if e.PaddingStart != 69 || e.PaddingBits != 3 || e.TrailingBytes != 0 || e.TotalBits != 72 {
+		t.Fatalf("unexpected padding info: %+v", e)
+	}
+}
+
+// TestExplain_TrailingAndErrors reports trailing bytes and returns partial fields on errors.
+func TestExplain_TrailingAndErrors(t *testing.T) {
+	p := testPack(1)
+	raw := append(rawCode(1, []uint32{0}, nil, nil), 0xff, 0xff)
+	e, err := Explain(p, base64.RawURLEncoding.EncodeToString(raw))
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if e.TrailingBytes != 2 {
+		t.Fatalf("trailing bytes: got %d want 2", e.TrailingBytes)
+	}
+
+	bad := base64.RawURLEncoding.EncodeToString(rawCode(1, []uint32{30}, nil, nil))
+	e, err = Explain(p, bad)
+	if !errors.Is(err, ErrOrdinalRange) {
+		t.Fatalf("expected ErrOrdinalRange, got %v", err)
+	}
+	last := e.Fields[len(e.Fields)-1]
+	if last.Label() != "leader[0].ordinal" || last.Raw != 30 || last.HasPK {
+		t.Fatalf("failing field should be reported without PK: %+v", last)
+	}
+}
+
+// TestExplain_Renderers smoke-tests the text and JSON renderers.
+func TestExplain_Renderers(t *testing.T) {
+	p := testPack(1)
+	code, _ := Encode(p, DeckInput{Leader: []uint64{412}, Deck: map[uint64]uint8{2117: 2}})
+	e, err := Explain(p, code)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	var txt bytes.Buffer
+	if err := e.WriteText(&txt); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	for _, s := range []string{"format_id=1", "leader[0].ordinal", "412", "deck[0].count", "padding:"} {
+		if !strings.Contains(txt.String(), s) {
+			t.Fatalf("text output missing %q:\n%s", s, txt.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := e.WriteJSON(&js); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	var back struct {
+		Fields []struct {
+			Section string `json:"section"`
+			PK      uint64 `json:"pk"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(js.Bytes(), &back); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if back.Fields[2].Section != "leader" || back.Fields[2].PK != 412 {
+		t.Fatalf("unexpected JSON field: %+v", back.Fields[2])
+	}
+}
@@ -0,0 +1,218 @@
+package deckcodec
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/Argonauts-inc/deckcodec/bitstream"
+)
+
+// Code headers.
+//
+// The legacy header is the 16-bit format ID. Format ID 0 is never valid, so it
+// escapes to the extended header:
+//
+//	escape    16 bits  0 (never a valid format ID)
+//	version    4 bits  1
+//	format_id 16 bits
+//	flags      4 bits  headerEpoch | headerFingerprint
+//	epoch      Elias-gamma, if headerEpoch: pack size M the code was encoded against
+//	fp        16 bits, if headerFingerprint: PackFingerprint of the first M cards
+//
+// Codes for append-only packs record their epoch so ordinals keep their width
+// when the pack grows; codes encoded with EncodeOpts.Fingerprint carry a pack
+// fingerprint. All other codes keep the legacy header.
+const (
+	extVersion = 1
+
+	headerEpoch       = 1 << 0 // epoch M follows the flags
+	headerFingerprint = 1 << 1 // 16-bit pack fingerprint follows
+	knownFlags        = headerEpoch | headerFingerprint
+)
+
+// header describes the header a code is written with.
+type header struct {
+	fid   uint16
+	m     int    // pack size the ordinals index into
+	epoch bool   // record m in an extended header
+	hasFP bool   // record fp in an extended header
+	fp    uint16 // pack fingerprint
+}
+
+// extended reports whether h needs the extended header.
+func (h header) extended() bool { return h.epoch || h.hasFP }
+
+// gammaBits returns the length of the Elias-gamma code of v (v >= 1).
+func gammaBits(v uint64) int {
+	return 2*bits.Len64(v) - 1
+}
+
+// bits returns the number of header bits.
+func (h header) bits() int {
+	if !h.extended() {
+		return 16
+	}
+	n := 16 + 4 + 16 + 4
+	if h.epoch {
+		n += gammaBits(uint64(h.m))
+	}
+	if h.hasFP {
+		n += 16
+	}
+	return n
+}
+
+// write writes the header to bw.
+func (h header) write(bw *bitstream.Writer) {
+	if !h.extended() {
+		bw.WriteBits(uint64(h.fid), 16)
+		return
+	}
+	var flags uint64
+	if h.epoch {
+		flags |= headerEpoch
+	}
+	if h.hasFP {
+		flags |= headerFingerprint
+	}
+	bw.WriteBits(0, 16)
+	bw.WriteBits(extVersion, 4)
+	bw.WriteBits(uint64(h.fid), 16)
+	bw.WriteBits(flags, 4)
+	if h.epoch {
+		bw.WriteGamma(uint64(h.m))
+	}
+	if h.hasFP {
+		bw.WriteBits(uint64(h.fp), 16)
+	}
+}
+
+// codeHeader is a header as read from a code.
+type codeHeader struct {
+	fid     uint16
+	version int // 0 for the legacy header
+	flags   uint8
+	epoch   int    // recorded pack size, 0 if absent
+	fp      uint16 // pack fingerprint, valid if flags&headerFingerprint != 0
+}
+
+// hasFP reports whether the code carries a pack fingerprint.
+func (h codeHeader) hasFP() bool { return h.flags&headerFingerprint != 0 }
+
+// maxEpoch bounds a recorded pack size to what uint32 ordinals can index.
+const maxEpoch = 1 << 32
+
+// readHeader reads the legacy or extended header, reporting each field to
+// trace if it is non-nil.
+func readHeader(br *bitstream.Reader, trace func(Field)) (codeHeader, error) {
+	read := func(width int, name string) (uint64, error) {
+		off := br.Offset()
+		v, err := br.ReadBits(width)
+		if err != nil {
+			return 0, &TruncatedError{Section: SectionHeader, Offset: off}
+		}
+		if trace != nil {
+			trace(Field{Section: SectionHeader, Index: -1, Name: name, Offset: off, Width: width, Raw: v})
+		}
+		return v, nil
+	}
+	fid, err := br.ReadBits(16)
+	if err != nil {
+		return codeHeader{}, &TruncatedError{Section: SectionHeader, Offset: 0}
+	}
+	if fid != 0 {
+		if trace != nil {
+			trace(Field{Section: SectionHeader, Index: -1, Name: "format_id", Width: 16, Raw: fid})
+		}
+		return codeHeader{fid: uint16(fid)}, nil
+	}
+	if trace != nil {
+		trace(Field{Section: SectionHeader, Index: -1, Name: "escape", Width: 16})
+	}
+
+	// Extended header.
+	v, err := read(4, "version")
+	if err != nil {
+		return codeHeader{}, err
+	}
+	if v != extVersion {
+		return codeHeader{}, fmt.Errorf("%w: unsupported header version %d", ErrInvalidCode, v)
+	}
+	if fid, err = read(16, "format_id"); err != nil {
+		return codeHeader{}, err
+	}
+	if fid == 0 {
+		return codeHeader{}, fmt.Errorf("%w: zero format_id", ErrInvalidCode)
+	}
+	flags, err := read(4, "flags")
+	if err != nil {
+		return codeHeader{}, err
+	}
+	if flags&^knownFlags != 0 {
+		return codeHeader{}, fmt.Errorf("%w: unknown header flags %#x", ErrInvalidCode, flags)
+	}
+	h := codeHeader{fid: uint16(fid), version: int(v), flags: uint8(flags)}
+	if flags&headerEpoch != 0 {
+		off := br.Offset()
+		m, err := br.ReadGamma()
+		if errors.Is(err, bitstream.ErrShort) {
+			return codeHeader{}, &TruncatedError{Section: SectionHeader, Offset: off}
+		}
+		if err != nil || m > maxEpoch {
+			return codeHeader{}, fmt.Errorf("%w: epoch out of range", ErrInvalidCode)
+		}
+		if trace != nil {
+			trace(Field{Section: SectionHeader, Index: -1, Name: "epoch", Offset: off, Width: br.Offset() - off, Raw: m})
+		}
+		h.epoch = int(m)
+	}
+	if h.hasFP() {
+		fp, err := read(16, "fingerprint")
+		if err != nil {
+			return codeHeader{}, err
+		}
+		h.fp = uint16(fp)
+	}
+	return h, nil
+}
+
+// PackFingerprint returns the 16-bit fingerprint of p that codes encoded with
+// EncodeOpts.Fingerprint carry. It covers the format ID and the cards in
+// ordinal order, so a stale, edited or corrupted pack with the right format ID
+// is detected at decode with probability 1 - 2^-16.
+func PackFingerprint(p Pack) uint16 {
+	return fingerprint(p.FormatID, p.Cards)
+}
+
+// fingerprint hashes fid and cards (in ordinal order) down to 16 bits.
+// For append-only packs, codes carry the fingerprint of their epoch's prefix.
+func fingerprint(fid uint16, cards []uint64) uint16 {
+	f := newFingerprinter(fid)
+	for _, pk := range cards {
+		f.add(pk)
+	}
+	return f.sum()
+}
+
+// fingerprinter computes fingerprints of growing card prefixes incrementally:
+// a 64-bit multiply-xor hash over the card words, finalized with the
+// splitmix64 mixer and folded to 16 bits.
+type fingerprinter struct{ h uint64 }
+
+func newFingerprinter(fid uint16) fingerprinter {
+	return fingerprinter{h: 0xcbf29ce484222325 ^ uint64(fid)}
+}
+
+func (f *fingerprinter) add(pk uint64) {
+	f.h = (f.h ^ pk) * 0x100000001b3
+	f.h = bits.RotateLeft64(f.h, 31)
+}
+
+func (f fingerprinter) sum() uint16 {
+	z := f.h
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return uint16(z ^ z>>16 ^ z>>32 ^ z>>48)
+}
@@ -0,0 +1,400 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Argonauts-inc/deckcodec/bitstream"
+)
+
+// appendOnlyPack returns a 4-card append-only pack, deliberately not sorted.
+func appendOnlyPack(t *testing.T) Pack {
+	t.Helper()
+	p, err := BuildPack([]uint64{900, 100, 500, 100, 300}, PackBuildOpts{FormatID: 60, AppendOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestBuildPack_AppendOnly(t *testing.T) {
+	v1 := appendOnlyPack(t)
+	if !v1.AppendOnly || !slices.Equal(v1.Cards, []uint64{900, 100, 500, 300}) {
+		t.Fatalf("v1 = %+v", v1)
+	}
+	v2, err := AppendPack(v1, []uint64{500, 50, 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(v2.Cards, []uint64{900, 100, 500, 300, 50, 1000}) || v2.FormatID != 60 {
+		t.Fatalf("v2 = %+v", v2)
+	}
+	if len(v1.Cards) != 4 {
+		t.Fatal("AppendPack modified prev")
+	}
+
+	legacy := testPack(60)
+	for name, tc := range map[string]struct {
+		pks  []uint64
+		prev *Pack
+	}{
+		"reordered":  {[]uint64{100, 900, 500, 300, 7}, &v1},
+		"removed":    {[]uint64{900, 100, 300, 7}, &v1},
+		"shrunk":     {[]uint64{900, 100}, &v1},
+		"not append": {legacy.Cards, &legacy},
+		"other id":   {v1.Cards, &Pack{FormatID: 61, AppendOnly: true, Cards: v1.Cards}},
+	} {
+		_, err := BuildPack(tc.pks, PackBuildOpts{FormatID: 60, Previous: tc.prev})
+		var pe *PackError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidPack) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+// TestAppendOnly_OldCodesDecode checks that codes keep decoding exactly after
+// the pack grows, including across an ordinal width change (M 4 → 6).
+func TestAppendOnly_OldCodesDecode(t *testing.T) {
+	v1 := appendOnlyPack(t)
+	v2, _ := AppendPack(v1, []uint64{50, 1000})
+	in := DeckInput{Leader: []uint64{300}, Tactics: []uint64{100, 900}, Deck: map[uint64]uint8{500: 3, 900: 1}}
+
+	old, err := Encode(v1, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []Pack{v1, v2} {
+		out, err := Decode(p, old)
+		if err != nil {
+			t.Fatalf("M=%d: %v", len(p.Cards), err)
+		}
+		if !Equal(in, out.ToInput()) {
+			t.Fatalf("M=%d: decoded %+v, want %+v", len(p.Cards), out, in)
+		}
+		if _, err := DecodeWithOpts(p, old, DecodeOpts{Strict: true}); err != nil {
+			t.Fatalf("M=%d strict: %v", len(p.Cards), err)
+		}
+	}
+	// Sections are sorted by ordinal (insertion order), not by PK.
+	out, _ := Decode(v2, old)
+	if !slices.Equal(out.Tactics, []uint64{900, 100}) {
+		t.Fatalf("tactics = %v, want insertion order", out.Tactics)
+	}
+
+	// A code for the grown pack cannot be decoded with the old one.
+	in.Deck[1000] = 2
+	code, err := Encode(v2, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ee *EpochError
+	if _, err := Decode(v1, code); !errors.As(err, &ee) || !errors.Is(err, ErrFormatMismatch) || ee.Code != 6 || ee.Pack != 4 {
+		t.Fatalf("Decode with old pack: %v", err)
+	}
+
+	// A sorted pack rejects any epoch: its ordinals follow PK order, so even
+	// with the same M the code would read the wrong cards.
+	sorted := Pack{FormatID: 60, Cards: slices.Sorted(slices.Values(v1.Cards))}
+	for _, opts := range []DecodeOpts{{}, {Strict: true}} {
+		if _, err := DecodeWithOpts(sorted, old, opts); !errors.As(err, &ee) || !errors.Is(err, ErrFormatMismatch) || ee.AppendOnly {
+			t.Fatalf("sorted pack, same M, %+v: %v", opts, err)
+		}
+	}
+	if _, err := Decode(Pack{FormatID: 60, Cards: slices.Sorted(slices.Values(v2.Cards))}, old); !errors.As(err, &ee) {
+		t.Fatalf("sorted pack, other M: %v", err)
+	}
+
+	// Codec, EstimateLength, Inspect and DecodeOrdinals agree with the header.
+	c, err := NewCodec(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc, _ := c.Encode(in); cc != code {
+		t.Fatalf("Codec.Encode = %q, Encode = %q", cc, code)
+	}
+	if n, _ := EstimateLength(v2, in); n != len(code) {
+		t.Fatalf("EstimateLength = %d, len(code) = %d", n, len(code))
+	}
+	ci, err := InspectM(old, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.FormatID != 60 || ci.HeaderVersion != 1 || ci.Epoch != 4 || ci.M != 4 || !ci.Consistent {
+		t.Fatalf("InspectM = %+v", ci)
+	}
+	o, err := DecodeOrdinals(old, 0)
+	if err != nil || o.M != 4 || !o.Epoch {
+		t.Fatalf("DecodeOrdinals = %+v, %v", o, err)
+	}
+	if again, _ := EncodeOrdinals(o); again != old {
+		t.Fatalf("EncodeOrdinals = %q, want %q", again, old)
+	}
+	if out, err := o.Resolve(v2); err != nil || !slices.Equal(out.Leader, []uint64{300}) {
+		t.Fatalf("Resolve with grown pack = %+v, %v", out, err)
+	}
+	for _, m := range []int{0, -1} {
+		bad := Ordinals{FormatID: 60, M: m, Epoch: true, HasFingerprint: true}
+		if _, err := bad.Resolve(v2); !errors.Is(err, ErrFormatMismatch) {
+			t.Fatalf("Resolve with M=%d: %v", m, err)
+		}
+	}
+
+	e, err := Explain(v2, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, f := range e.Fields[:5] {
+		labels = append(labels, f.Label())
+	}
+	if got := strings.Join(labels, " "); got != "escape version format_id flags epoch" || e.M != 4 || e.IDBits != 2 {
+		t.Fatalf("Explain header = %s (M=%d, id_bits=%d)", got, e.M, e.IDBits)
+	}
+}
+
+// TestAppendOnly_PKIndex checks that append-only packs from every constructor
+// carry the PK order Encode searches, and encode exactly like a Pack literal,
+// which scans its cards.
+func TestAppendOnly_PKIndex(t *testing.T) {
+	cards := make([]uint64, 500)
+	for i := range cards {
+		cards[i] = uint64((i*7919)%1000 + 1) // distinct, not sorted
+	}
+	built, err := BuildPack(cards, PackBuildOpts{FormatID: 61, AppendOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grown, _ := AppendPack(Pack{FormatID: 61, AppendOnly: true, Cards: cards[:300], byPK: pkOrder(cards[:300])}, cards[300:])
+	js, _ := json.Marshal(built)
+	parsed, err := ParsePack(bytes.NewReader(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unmarshaled, binPack Pack
+	if err := json.Unmarshal(js, &unmarshaled); err != nil {
+		t.Fatal(err)
+	}
+	bin, _ := built.MarshalBinary()
+	if err := binPack.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	literal := Pack{FormatID: 61, AppendOnly: true, Cards: cards}
+
+	in := DeckInput{Leader: []uint64{cards[499]}, Tactics: []uint64{cards[0], cards[250]}, Deck: map[uint64]uint8{}}
+	for _, pk := range cards[100:140] {
+		in.Deck[pk] = 2
+	}
+	want, err := Encode(literal, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, p := range map[string]Pack{"BuildPack": built, "AppendPack": grown, "ParsePack": parsed,
+		"UnmarshalJSON": unmarshaled, "UnmarshalBinary": binPack} {
+		if len(p.byPK) != len(cards) {
+			t.Fatalf("%s: no PK index", name)
+		}
+		d := packDict(p)
+		for o, pk := range cards {
+			if got, ok := d.lookup(pk); !ok || got != uint32(o) {
+				t.Fatalf("%s: lookup(%d) = %d, %v; want %d", name, pk, got, ok, o)
+			}
+		}
+		if _, ok := d.lookup(1001); ok {
+			t.Fatalf("%s: found a PK not in the pack", name)
+		}
+		if got, err := Encode(p, in); err != nil || got != want {
+			t.Fatalf("%s: Encode = %q, %v; want %q", name, got, err, want)
+		}
+	}
+}
+
+// TestAppendOnly_LegacyCodeRejected checks that a code without an epoch does
+// not decode with an append-only pack: it may predate the switch to append-only
+// or the pack's growth, and would be read with the wrong ordinals.
+func TestAppendOnly_LegacyCodeRejected(t *testing.T) {
+	v1 := appendOnlyPack(t)
+	in := DeckInput{Leader: []uint64{900}, Deck: map[uint64]uint8{100: 2}}
+	sorted := Pack{FormatID: 60, Cards: slices.Sorted(slices.Values(v1.Cards))}
+	legacy, err := Encode(sorted, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, _ := AppendPack(v1, []uint64{50, 1000})
+	for _, p := range []Pack{v1, v2} {
+		for _, opts := range []DecodeOpts{{}, {Strict: true}} {
+			var ee *EpochError
+			_, err := DecodeWithOpts(p, legacy, opts)
+			if !errors.As(err, &ee) || !errors.Is(err, ErrFormatMismatch) || ee.Code != 0 || !ee.AppendOnly {
+				t.Fatalf("M=%d, %+v: %v", len(p.Cards), opts, err)
+			}
+		}
+	}
+}
+
+func TestHeader_Invalid(t *testing.T) {
+	p := appendOnlyPack(t)
+	ext := func(version, flags uint64) string {
+		var w bitstream.Writer
+		w.WriteBits(0, 16)
+		w.WriteBits(version, 4)
+		w.WriteBits(60, 16)
+		w.WriteBits(flags, 4)
+		w.WriteGamma(4)
+		w.WriteBits(0, 24)
+		return base64.RawURLEncoding.EncodeToString(w.Finish())
+	}
+	if _, err := Decode(p, ext(1, headerEpoch)); err != nil {
+		t.Fatalf("valid extended header: %v", err)
+	}
+	for name, code := range map[string]string{
+		"version": ext(2, headerEpoch),
+		"flags":   ext(1, headerEpoch|8),
+	} {
+		if _, err := Decode(p, code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("%s: %v", name, err)
+		}
+		if _, err := Inspect(code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("Inspect %s: %v", name, err)
+		}
+	}
+	// Escape with nothing after it.
+	if _, err := Decode(p, "AAA"); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("bare escape: %v", err)
+	}
+}
+
+func TestAppendOnly_ParseAndManifest(t *testing.T) {
+	p, err := ParsePack(strings.NewReader(`{"format_id":9,"append_only":true,"cards":[30,10,20]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Cards, []uint64{30, 10, 20}) {
+		t.Fatalf("ParsePack sorted an append-only pack: %v", p.Cards)
+	}
+	if _, err := ParsePack(strings.NewReader(`{"format_id":9,"append_only":true,"cards":[30,10,30]}`)); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("duplicate PK: %v", err)
+	}
+	if _, err := NewCodec(Pack{FormatID: 9, AppendOnly: true, Cards: []uint64{30, 10, 30}}); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("NewCodec duplicate PK: %v", err)
+	}
+
+	m, err := BuildManifest([]Pack{p}, func(uint16) string { return "u" }, 1, time.Unix(0, 0), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Packs[0].AppendOnly || !slices.Equal(p.Cards, []uint64{30, 10, 20}) {
+		t.Fatalf("BuildManifest: meta %+v, cards %v", m.Packs[0], p.Cards)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil || !bytes.Contains(b, []byte(`"append_only":true`)) {
+		t.Fatalf("JSON = %s, %v", b, err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	p := testPack(70)
+	in := DeckInput{Leader: []uint64{101}, Tactics: []uint64{301}, Deck: map[uint64]uint8{1006: 2, 2117: 4}}
+	code, err := EncodeWithOpts(p, in, EncodeOpts{Fingerprint: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := NewCodec(p)
+	if cc, _ := c.EncodeWithOpts(in, EncodeOpts{Fingerprint: true}); cc != code {
+		t.Fatalf("Codec.EncodeWithOpts = %q, want %q", cc, code)
+	}
+	for _, dec := range []func(string) (DeckOutput, error){
+		func(s string) (DeckOutput, error) { return Decode(p, s) },
+		func(s string) (DeckOutput, error) {
+			return DecodeWithOpts(p, s, DecodeOpts{Strict: true, Fingerprint: true})
+		},
+		c.Decode,
+	} {
+		out, err := dec(code)
+		if err != nil || !Equal(in, out.ToInput()) {
+			t.Fatalf("decode = %+v, %v", out, err)
+		}
+	}
+
+	// Same format ID and size, one card replaced: only the fingerprint notices.
+	edited := Pack{FormatID: 70, Cards: slices.Clone(p.Cards)}
+	edited.Cards[0] = 100
+	plain, _ := Encode(p, in)
+	if _, err := Decode(edited, plain); err != nil {
+		t.Fatalf("plain code with edited pack: %v", err)
+	}
+	// Strict mode accepts one of the two codes, as chosen by DecodeOpts.Fingerprint.
+	for _, c := range []struct {
+		code string
+		opts DecodeOpts
+	}{
+		{code, DecodeOpts{Strict: true}},
+		{plain, DecodeOpts{Strict: true, Fingerprint: true}},
+	} {
+		if _, err := DecodeWithOpts(p, c.code, c.opts); !errors.Is(err, ErrNotCanonical) {
+			t.Fatalf("strict %+v: %v", c.opts, err)
+		}
+	}
+	var fe *FingerprintError
+	if _, err := Decode(edited, code); !errors.As(err, &fe) || !errors.Is(err, ErrPackMismatch) ||
+		fe.Code != PackFingerprint(p) || fe.Pack != PackFingerprint(edited) {
+		t.Fatalf("edited pack: %v", err)
+	}
+	if PackFingerprint(p) != c.d.fps[len(p.Cards)-1] {
+		t.Fatal("Codec prefix fingerprint differs from PackFingerprint")
+	}
+
+	// Sizes and inspection account for the fingerprint.
+	if n, _ := MaxCodeLengthWithOpts(p, SectionLimits{Leader: 1, Tactics: 1, Deck: 2}, EncodeOpts{Fingerprint: true}); n < len(code) {
+		t.Fatalf("MaxCodeLength = %d < %d", n, len(code))
+	}
+	ci, err := InspectM(code, len(p.Cards))
+	if err != nil || !ci.HasFingerprint || ci.Fingerprint != PackFingerprint(p) || !ci.Consistent || ci.FormatID != 70 {
+		t.Fatalf("InspectM = %+v, %v", ci, err)
+	}
+	e, _ := Explain(p, code)
+	if e.Fields[4].Label() != "fingerprint" || e.Fields[4].Width != 16 {
+		t.Fatalf("Explain field 4 = %+v", e.Fields[4])
+	}
+
+	// Ordinals keep the fingerprint and check it on Resolve.
+	o, err := DecodeOrdinals(code, len(p.Cards))
+	if err != nil || !o.HasFingerprint {
+		t.Fatalf("DecodeOrdinals = %+v, %v", o, err)
+	}
+	if again, _ := EncodeOrdinals(o); again != code {
+		t.Fatalf("EncodeOrdinals = %q, want %q", again, code)
+	}
+	if _, err := o.Resolve(edited); !errors.Is(err, ErrPackMismatch) {
+		t.Fatalf("Resolve with edited pack: %v", err)
+	}
+}
+
+func TestFingerprint_AppendOnlyEpochs(t *testing.T) {
+	v1 := appendOnlyPack(t)
+	v2, _ := AppendPack(v1, []uint64{50})
+	in := DeckInput{Deck: map[uint64]uint8{300: 1}}
+	code, err := EncodeWithOpts(v1, in, EncodeOpts{Fingerprint: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The code covers only the first 4 cards, which v2 keeps.
+	if _, err := Decode(v2, code); err != nil {
+		t.Fatalf("grown pack: %v", err)
+	}
+	c, _ := NewCodec(v2)
+	if _, err := c.Decode(code); err != nil {
+		t.Fatalf("grown pack via Codec: %v", err)
+	}
+	bad := Pack{FormatID: v2.FormatID, AppendOnly: true, Cards: slices.Clone(v2.Cards)}
+	bad.Cards[1], bad.Cards[3] = bad.Cards[3], bad.Cards[1]
+	if _, err := Decode(bad, code); !errors.Is(err, ErrPackMismatch) {
+		t.Fatalf("reordered pack: %v", err)
+	}
+}
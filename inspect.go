@@ -0,0 +1,152 @@
+package deckcodec
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Argonauts-inc/deckcodec/bitstream"
+)
+
+// CodeInfo describes the layout of a code as far as it can be read without a pack.
+// Fields that depend on the pack size M are only filled by InspectM.
+type CodeInfo struct {
+	FormatID       uint16
+	HeaderVersion  int // 0 for the legacy header, which has no version field
+	Epoch          int // pack size recorded in the code (append-only packs), 0 if absent
+	Fingerprint    uint16
+	HasFingerprint bool // the code carries a pack fingerprint (see PackFingerprint)
+	TotalBits      int  // len(raw bytes) * 8, including padding
+	Leader         int  // number of leader ordinals
+
+	// Filled by InspectM only (zero or -1 otherwise).
+	M           int  // pack size the layout was checked against
+	IDBits      int  // ordinal width for M
+	Tactics     int  // number of tactics ordinals (-1 if unknown)
+	Deck        int  // number of unique deck entries (-1 if unknown)
+	PayloadBits int  // bits used before padding (-1 if unknown)
+	Consistent  bool // code length is exactly what Encode would produce for M
+}
+
+// payloadBits returns the number of bits Encode writes for the given header
+// and section sizes (header + three 8-bit counts + ordinals + 2-bit counts).
+func payloadBits(h header, nL, nT, nD int) int {
+	return h.bits() + 3*8 + (nL+nT+nD)*idBits(h.m) + nD*2
+}
+
+// PeekFormatID returns the format ID of a code without decoding the rest.
+// Use it to pick the pack before calling Decode.
+func PeekFormatID(code string) (uint16, error) {
+	ci, err := Inspect(code)
+	if err != nil {
+		return 0, err
+	}
+	return ci.FormatID, nil
+}
+
+// Inspect reads the header of a code without a pack. Only the format ID and
+// the leader count can be read; the remaining section sizes need M (see InspectM).
+func Inspect(code string) (CodeInfo, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return CodeInfo{}, fmt.Errorf("%w: %w", ErrInvalidCode, err)
+	}
+	br := bitstream.NewReader(raw, len(raw)*8)
+	ci, err := inspectHeader(&br)
+	if err != nil {
+		return CodeInfo{}, err
+	}
+	ci.TotalBits = len(raw) * 8
+	return ci, nil
+}
+
+// InspectM is Inspect for a known pack size m: it walks the section sizes
+// (skipping ordinals) and reports whether the code length matches Encode's
+// output for m. It returns a *TruncatedError if the code is too short for m,
+// and a *PackError if m is not positive or exceeds what ordinals can index.
+// If the code records its epoch, that size is used and m is ignored.
+func InspectM(code string, m int) (CodeInfo, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return CodeInfo{}, fmt.Errorf("%w: %w", ErrInvalidCode, err)
+	}
+	br := bitstream.NewReader(raw, len(raw)*8)
+	ci, err := inspectHeader(&br)
+	if err != nil {
+		return CodeInfo{}, err
+	}
+	ci.TotalBits = len(raw) * 8
+	if ci.Epoch != 0 {
+		m = ci.Epoch
+	}
+	if m < 1 {
+		return CodeInfo{}, &PackError{Reason: "M must be positive", Err: ErrInvalidPack}
+	}
+	if uint64(m) > maxEpoch {
+		return CodeInfo{}, &PackError{Reason: "M exceeds the ordinal range", Err: ErrInvalidPack}
+	}
+	ci.M = m
+	ci.IDBits = idBits(m)
+
+	// skip moves past n fixed-width fields; readCount reads the next 8-bit section size.
+	skip := func(n, width int, sec Section) error {
+		for range n {
+			off := br.Offset()
+			if _, err := br.ReadBits(width); err != nil {
+				return &TruncatedError{Section: sec, Offset: off}
+			}
+		}
+		return nil
+	}
+	readCount := func(sec Section) (int, error) {
+		off := br.Offset()
+		v, err := br.ReadBits(8)
+		if err != nil {
+			return 0, &TruncatedError{Section: sec, Offset: off}
+		}
+		return int(v), nil
+	}
+
+	if err := skip(ci.Leader, ci.IDBits, SectionLeader); err != nil {
+		return CodeInfo{}, err
+	}
+	if ci.Tactics, err = readCount(SectionTactics); err != nil {
+		return CodeInfo{}, err
+	}
+	if err := skip(ci.Tactics, ci.IDBits, SectionTactics); err != nil {
+		return CodeInfo{}, err
+	}
+	if ci.Deck, err = readCount(SectionDeck); err != nil {
+		return CodeInfo{}, err
+	}
+	if err := skip(ci.Deck, ci.IDBits+2, SectionDeck); err != nil {
+		return CodeInfo{}, err
+	}
+	h := header{fid: ci.FormatID, m: m, epoch: ci.Epoch != 0, hasFP: ci.HasFingerprint}
+	ci.PayloadBits = payloadBits(h, ci.Leader, ci.Tactics, ci.Deck)
+	ci.Consistent = len(raw) == (ci.PayloadBits+7)/8
+	return ci, nil
+}
+
+// inspectHeader reads the header fields and the leader count.
+func inspectHeader(br *bitstream.Reader) (CodeInfo, error) {
+	h, err := readHeader(br, nil)
+	if err != nil {
+		return CodeInfo{}, err
+	}
+	off := br.Offset()
+	nL, err := br.ReadBits(8)
+	if err != nil {
+		return CodeInfo{}, &TruncatedError{Section: SectionLeader, Offset: off}
+	}
+	return CodeInfo{
+		FormatID:       h.fid,
+		HeaderVersion:  h.version,
+		Epoch:          h.epoch,
+		Fingerprint:    h.fp,
+		HasFingerprint: h.hasFP(),
+		Leader:         int(nL),
+		Tactics:        -1,
+		Deck:           -1,
+		PayloadBits:    -1,
+	}, nil
+}
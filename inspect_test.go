@@ -0,0 +1,105 @@
+package deckcodec
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestInspect_PackFree reads the format ID and leader count without a pack.
+func TestInspect_PackFree(t *testing.T) {
+	p := testPack(513)
+	code, err := Encode(p, DeckInput{
+		Leader:  []uint64{101, 205, 303},
+		Tactics: []uint64{301, 402},
+		Deck:    map[uint64]uint8{501: 4, 602: 1},
+	})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	fid, err := PeekFormatID(code)
+	if err != nil || fid != 513 {
+		t.Fatalf("PeekFormatID: got %d, %v", fid, err)
+	}
+	ci, err := Inspect(code)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if ci.FormatID != 513 || ci.HeaderVersion != 0 || ci.Leader != 3 {
+		t.Fatalf("unexpected header info: %+v", ci)
+	}
+	if ci.Tactics != -1 || ci.Deck != -1 || ci.PayloadBits != -1 || ci.Consistent {
+		t.Fatalf("M-dependent fields must be unknown: %+v", ci)
+	}
+}
+
+// TestInspectM_Sizes walks section sizes for the right M and checks consistency
+// against the bytes Encode produced.
+func TestInspectM_Sizes(t *testing.T) {
+	p := testPack(1)
+	code, err := Encode(p, DeckInput{
+		Leader:  []uint64{101, 205, 303, 412},
+		Tactics: []uint64{301, 402, 503, 604, 705},
+		Deck:    map[uint64]uint8{501: 4, 602: 3, 703: 2},
+	})
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	ci, err := InspectM(code, len(p.Cards))
+	if err != nil {
+		t.Fatalf("InspectM failed: %v", err)
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(code)
+	if ci.Leader != 4 || ci.Tactics != 5 || ci.Deck != 3 || ci.IDBits != 5 {
+		t.Fatalf("unexpected sizes: %+v", ci)
+	}
+	if want := 16 + 24 + 12*5 + 3*2; ci.PayloadBits != want {
+		t.Fatalf("payload bits: got %d want %d", ci.PayloadBits, want)
+	}
+	if !ci.Consistent || ci.TotalBits != len(raw)*8 {
+		t.Fatalf("expected consistent length: %+v (bytes=%d)", ci, len(raw))
+	}
+
+	// A pack with a wider id_bits misreads the layout: either inconsistent or truncated.
+	if ci, err := InspectM(code, 1000); err == nil && ci.Consistent {
+		t.Fatalf("M=1000 should not be consistent with a code built for M=26: %+v", ci)
+	}
+
+	// M must be positive and within what ordinals can index (only reachable
+	// where int is wider than 32 bits).
+	ms := []int{0, -5}
+	if math.MaxInt > maxEpoch {
+		ms = append(ms, math.MaxInt)
+	}
+	for _, m := range ms {
+		var pe *PackError
+		if _, err := InspectM(code, m); !errors.As(err, &pe) || !errors.Is(err, ErrInvalidPack) {
+			t.Fatalf("M=%d: got %v", m, err)
+		}
+	}
+
+	// Trailing bytes make the code inconsistent for the right M.
+	long := base64.RawURLEncoding.EncodeToString(append(raw, 0, 0, 0))
+	if ci, err := InspectM(long, len(p.Cards)); err != nil || ci.Consistent {
+		t.Fatalf("trailing bytes: got %+v, %v", ci, err)
+	}
+}
+
+// TestInspect_Errors covers bad Base64, truncated headers and zero format IDs.
+func TestInspect_Errors(t *testing.T) {
+	if _, err := Inspect("!!"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("bad base64: got %v", err)
+	}
+	if _, err := Inspect("AQ"); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("short header: got %v", err)
+	}
+	if _, err := Inspect("AAAA"); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("zero format_id: got %v", err)
+	}
+	var te *TruncatedError
+	if _, err := InspectM("AQAE", 26); !errors.As(err, &te) || te.Section != SectionLeader {
+		t.Fatalf("truncated leader section: got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package deckcodec
+
+import "encoding/base64"
+
+// SectionLimits bounds the section sizes of a deck for MaxCodeLength.
+// Each limit is the maximum number of entries (0..255) in that section:
+// leader and tactics cards, and unique cards in the main deck. Negative
+// limits count as 0.
+type SectionLimits struct {
+	Leader  int
+	Tactics int
+	Deck    int
+}
+
+// codeLen returns the number of Base64URL characters for a bit stream of the given length.
+func codeLen(bits int) int {
+	return base64.RawURLEncoding.EncodedLen((bits + 7) / 8)
+}
+
+// packHeader returns the header Encode writes for p.
+func packHeader(p Pack) header {
+	return header{fid: p.FormatID, m: len(p.Cards), epoch: p.AppendOnly}
+}
+
+// EstimateBits returns the number of payload bits (before padding) that Encode
+// would write for in, without mapping PKs to ordinals. It fails where Encode
+// would on the pack or section sizes; unknown PKs and counts are not checked.
+// Main deck printings of one untracked reprint share an entry, so such decks
+// encode shorter than estimated.
+func EstimateBits(p Pack, in DeckInput) (int, error) {
+	return EstimateBitsWithOpts(p, in, EncodeOpts{})
+}
+
+// EstimateBitsWithOpts is EstimateBits for the code EncodeWithOpts writes
+// with opts.
+func EstimateBitsWithOpts(p Pack, in DeckInput, opts EncodeOpts) (int, error) {
+	if err := checkEncodePack(p); err != nil {
+		return 0, err
+	}
+	if err := checkSectionLens(len(in.Leader), len(in.Tactics), len(in.Deck)); err != nil {
+		return 0, err
+	}
+	h := packHeader(p)
+	h.hasFP = opts.Fingerprint
+	return payloadBits(h, len(in.Leader), len(in.Tactics), len(in.Deck)), nil
+}
+
+// EstimateLength is EstimateBits converted to the length of the code string.
+func EstimateLength(p Pack, in DeckInput) (int, error) {
+	return EstimateLengthWithOpts(p, in, EncodeOpts{})
+}
+
+// EstimateLengthWithOpts is EstimateBitsWithOpts converted to the length of
+// the code string.
+func EstimateLengthWithOpts(p Pack, in DeckInput, opts EncodeOpts) (int, error) {
+	bits, err := EstimateBitsWithOpts(p, in, opts)
+	if err != nil {
+		return 0, err
+	}
+	return codeLen(bits), nil
+}
+
+// MaxCodeLength returns the exact length in characters of the longest code
+// Encode can produce for p when every section stays within lim.
+// Use it to check that share URLs or QR codes fit a platform's limit.
+func MaxCodeLength(p Pack, lim SectionLimits) (int, error) {
+	return MaxCodeLengthWithOpts(p, lim, EncodeOpts{})
+}
+
+// MaxCodeLengthWithOpts is MaxCodeLength for the codes EncodeWithOpts writes
+// with opts.
+func MaxCodeLengthWithOpts(p Pack, lim SectionLimits, opts EncodeOpts) (int, error) {
+	if err := checkEncodePack(p); err != nil {
+		return 0, err
+	}
+	nL, nT, nD := max(lim.Leader, 0), max(lim.Tactics, 0), max(lim.Deck, 0)
+	if err := checkSectionLens(nL, nT, nD); err != nil {
+		return 0, err
+	}
+	// The layout is fixed-width per entry, so the longest code is the one
+	// with every section full.
+	h := packHeader(p)
+	h.hasFP = opts.Fingerprint
+	return codeLen(payloadBits(h, nL, nT, nD)), nil
+}
+
+// checkSectionLens reports the first section longer than the 8-bit count allows.
+func checkSectionLens(nL, nT, nD int) error {
+	switch {
+	case nL > 255:
+		return &SectionTooLongError{Section: SectionLeader, Len: nL}
+	case nT > 255:
+		return &SectionTooLongError{Section: SectionTactics, Len: nT}
+	case nD > 255:
+		return &SectionTooLongError{Section: SectionDeck, Len: nD}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package deckcodec
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestEstimate_MatchesEncode checks EstimateBits/EstimateLength against real
+// encodes across pack sizes and section sizes.
+func TestEstimate_MatchesEncode(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for _, m := range []int{1, 2, 3, 26, 255, 256, 2000, 70000} {
+		p := benchPack(5, m)
+		for range 50 {
+			pick := func(n int) []uint64 {
+				out := make([]uint64, n)
+				for i := range out {
+					out[i] = p.Cards[rnd.Intn(m)]
+				}
+				return out
+			}
+			in := DeckInput{Leader: pick(rnd.Intn(4)), Tactics: pick(rnd.Intn(12)), Deck: map[uint64]uint8{}}
+			for _, pk := range pick(rnd.Intn(60)) {
+				in.Deck[pk] = uint8(1 + rnd.Intn(4))
+			}
+			code, err := Encode(p, in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, _ := EncodeBytes(p, in)
+			bits, err := EstimateBits(p, in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (bits+7)/8 != len(raw) || bits <= (len(raw)-1)*8 {
+				t.Fatalf("M=%d: EstimateBits=%d, raw is %d bytes", m, bits, len(raw))
+			}
+			if n, _ := EstimateLength(p, in); n != len(code) {
+				t.Fatalf("M=%d: EstimateLength=%d, len(code)=%d", m, n, len(code))
+			}
+		}
+	}
+}
+
+// TestEstimateWithOpts_Fingerprint checks the estimate for fingerprinted
+// codes against EncodeWithOpts, for sorted and append-only packs.
+func TestEstimateWithOpts_Fingerprint(t *testing.T) {
+	ao := benchPack(6, 300)
+	ao.AppendOnly = true
+	opts := EncodeOpts{Fingerprint: true}
+	for _, p := range []Pack{benchPack(5, 300), ao} {
+		for _, in := range []DeckInput{
+			{},
+			{Leader: p.Cards[:2], Tactics: p.Cards[5:9], Deck: map[uint64]uint8{p.Cards[20]: 4, p.Cards[299]: 1}},
+		} {
+			code, err := EncodeWithOpts(p, in, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, err := EstimateLengthWithOpts(p, in, opts); err != nil || n != len(code) {
+				t.Fatalf("append-only %v: EstimateLengthWithOpts=%d, %v; len(code)=%d", p.AppendOnly, n, err, len(code))
+			}
+			plain, _ := EstimateBits(p, in)
+			if bits, _ := EstimateBitsWithOpts(p, in, opts); bits <= plain {
+				t.Fatalf("append-only %v: fingerprint estimate %d, plain %d", p.AppendOnly, bits, plain)
+			}
+		}
+	}
+}
+
+func TestMaxCodeLength(t *testing.T) {
+	lim := SectionLimits{Leader: 2, Tactics: 10, Deck: 50}
+	for _, m := range []int{50, 64, 2000} {
+		p := benchPack(5, m)
+		// Fill every section with the highest ordinals available.
+		last := p.Cards[m-1]
+		in := DeckInput{
+			Leader:  []uint64{last, last},
+			Tactics: []uint64{last, last, last, last, last, last, last, last, last, last},
+			Deck:    map[uint64]uint8{},
+		}
+		for _, pk := range p.Cards[m-50:] {
+			in.Deck[pk] = 4
+		}
+		code, err := Encode(p, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MaxCodeLength(p, lim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != got {
+			t.Fatalf("M=%d: full deck encodes to %d chars, MaxCodeLength=%d", m, len(code), got)
+		}
+		opts := EncodeOpts{Fingerprint: true}
+		fp, _ := EncodeWithOpts(p, in, opts)
+		if got, err := MaxCodeLengthWithOpts(p, lim, opts); err != nil || len(fp) != got {
+			t.Fatalf("M=%d: fingerprinted deck encodes to %d chars, MaxCodeLengthWithOpts=%d, %v", m, len(fp), got, err)
+		}
+	}
+
+	p := testPack(1)
+	empty, _ := Encode(p, DeckInput{})
+	if n, _ := MaxCodeLength(p, SectionLimits{Leader: -1}); n != len(empty) {
+		t.Fatalf("empty limits: MaxCodeLength=%d, empty deck is %d chars", n, len(empty))
+	}
+	if _, err := MaxCodeLength(p, SectionLimits{Deck: 256}); !errors.Is(err, ErrSectionTooLong) {
+		t.Fatalf("limit 256: %v", err)
+	}
+	if _, err := MaxCodeLength(Pack{FormatID: 1}, SectionLimits{}); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("empty pack: %v", err)
+	}
+	if _, err := EstimateBits(p, DeckInput{Tactics: make([]uint64, 256)}); !errors.Is(err, ErrSectionTooLong) {
+		t.Fatalf("EstimateBits long tactics: %v", err)
+	}
+}
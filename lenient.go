@@ -0,0 +1,107 @@
+package deckcodec
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CodeFix is a bit set of the normalizations NormalizeCode applied to a code.
+// A zero value means the input was already a canonical Base64URL code.
+type CodeFix uint8
+
+const (
+	FixWhitespace  CodeFix = 1 << iota // removed leading, trailing or embedded whitespace
+	FixQuotes                          // removed surrounding quotes (ASCII or typographic)
+	FixPadding                         // removed trailing '=' padding
+	FixStdAlphabet                     // mapped standard Base64 '+' and '/' to '-' and '_'
+)
+
+var codeFixNames = []struct {
+	f    CodeFix
+	name string
+}{
+	{FixWhitespace, "whitespace"},
+	{FixQuotes, "quotes"},
+	{FixPadding, "padding"},
+	{FixStdAlphabet, "std-alphabet"},
+}
+
+// Has reports whether all fixes in g were applied.
+func (f CodeFix) Has(g CodeFix) bool { return f&g == g }
+
+// String returns the applied fixes joined by '|' (e.g. "whitespace|padding"),
+// or "none" if nothing was changed.
+func (f CodeFix) String() string {
+	if f == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, n := range codeFixNames {
+		if f.Has(n.f) {
+			parts = append(parts, n.name)
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// quotePairs lists the opening/closing quote pairs stripped from pasted codes.
+var quotePairs = [][2]rune{
+	{'"', '"'},
+	{'\'', '\''},
+	{'`', '`'},
+	{'“', '”'},
+	{'‘', '’'},
+	{'«', '»'},
+}
+
+// NormalizeCode turns a pasted deck code into canonical Base64URL (no padding).
+// It strips whitespace and surrounding quotes, drops '=' padding and maps the
+// standard Base64 alphabet ('+', '/') to the URL-safe one. The returned CodeFix
+// records which of these normalizations changed the input.
+//
+// NormalizeCode never fails; characters outside both alphabets are left in
+// place so that Decode reports them.
+func NormalizeCode(code string) (string, CodeFix) {
+	var fixes CodeFix
+
+	s := strings.TrimFunc(code, unicode.IsSpace)
+	if len(s) != len(code) {
+		fixes |= FixWhitespace
+	}
+	// Messengers sometimes wrap codes in quotes; strip matching pairs.
+	for stripped := true; stripped; {
+		stripped = false
+		for _, q := range quotePairs {
+			lq, rq := string(q[0]), string(q[1])
+			if len(s) >= len(lq)+len(rq) && strings.HasPrefix(s, lq) && strings.HasSuffix(s, rq) {
+				inner := s[len(lq) : len(s)-len(rq)]
+				s = strings.TrimFunc(inner, unicode.IsSpace)
+				if len(s) != len(inner) {
+					fixes |= FixWhitespace
+				}
+				fixes |= FixQuotes
+				stripped = true
+				break
+			}
+		}
+	}
+	// Embedded whitespace (line wraps in chat clients).
+	if strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, s)
+		fixes |= FixWhitespace
+	}
+	if t := strings.TrimRight(s, "="); len(t) != len(s) {
+		s = t
+		fixes |= FixPadding
+	}
+	if strings.ContainsAny(s, "+/") {
+		s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+		fixes |= FixStdAlphabet
+	}
+	return s, fixes
+}
@@ -0,0 +1,85 @@
+package deckcodec
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// TestNormalizeCode covers each normalization and the recorded fixes.
+func TestNormalizeCode(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    string
+		want  string
+		fixes CodeFix
+	}{
+		{"canonical", "AQAEAAA", "AQAEAAA", 0},
+		{"trailing newline", "AQAEAAA\n", "AQAEAAA", FixWhitespace},
+		{"wrapped lines", "AQAE\r\nAAA", "AQAEAAA", FixWhitespace},
+		{"double quotes", `"AQAEAAA"`, "AQAEAAA", FixQuotes},
+		{"typographic quotes + space", "“ AQAEAAA ”", "AQAEAAA", FixQuotes | FixWhitespace},
+		{"nested quotes", `'"AQAEAAA"'`, "AQAEAAA", FixQuotes},
+		{"padding", "AQAEAAA=", "AQAEAAA", FixPadding},
+		{"std alphabet", "a+b/c", "a-b_c", FixStdAlphabet},
+		{"everything", " \"a+b/c==\"\n", "a-b_c", FixWhitespace | FixQuotes | FixPadding | FixStdAlphabet},
+		{"unbalanced quote kept", `"AQAEAAA`, `"AQAEAAA`, 0},
+	}
+	for _, tc := range cases {
+		got, fixes := NormalizeCode(tc.in)
+		if got != tc.want || fixes != tc.fixes {
+			t.Fatalf("%s: got (%q, %s) want (%q, %s)", tc.name, got, fixes, tc.want, tc.fixes)
+		}
+	}
+}
+
+// TestCodeFix_String checks the log-friendly rendering.
+func TestCodeFix_String(t *testing.T) {
+	if s := CodeFix(0).String(); s != "none" {
+		t.Fatalf("zero fixes: got %q", s)
+	}
+	if s := (FixWhitespace | FixPadding).String(); s != "whitespace|padding" {
+		t.Fatalf("got %q", s)
+	}
+}
+
+// TestDecodeWithOpts_Lenient decodes a code mangled the way messengers do
+// (standard alphabet, padding, quotes, trailing newline) and checks the fixes.
+func TestDecodeWithOpts_Lenient(t *testing.T) {
+	p := testPack(1)
+	in := DeckInput{
+		Leader:  []uint64{101, 205, 303, 412},
+		Tactics: []uint64{301, 402, 503, 604, 705},
+		Deck:    map[uint64]uint8{1915: 4, 2117: 3}, // chosen so the code contains '-' and needs padding
+	}
+	code, err := Encode(p, in)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(code)
+	std := base64.StdEncoding.EncodeToString(raw)
+	if !strings.ContainsAny(std, "+/") || !strings.HasSuffix(std, "=") {
+		t.Fatalf("test code should exercise std alphabet and padding, got %q", std)
+	}
+	mangled := "\"" + std + "\"\n"
+
+	if _, err := Decode(p, mangled); err == nil {
+		t.Fatalf("Decode without Lenient accepted a mangled code")
+	}
+	out, err := DecodeWithOpts(p, mangled, DecodeOpts{Lenient: true})
+	if err != nil {
+		t.Fatalf("lenient decode failed: %v", err)
+	}
+	if !equalDeckCounts(out.Deck, in.Deck) {
+		t.Fatalf("deck mismatch: got=%v want=%v", out.Deck, in.Deck)
+	}
+	if want := FixWhitespace | FixQuotes | FixPadding | FixStdAlphabet; out.Fixes != want {
+		t.Fatalf("fixes: got %s want %s", out.Fixes, want)
+	}
+
+	// A canonical code decodes without recording any fixes.
+	out, err = DecodeWithOpts(p, code, DecodeOpts{Lenient: true})
+	if err != nil || out.Fixes != 0 {
+		t.Fatalf("canonical code: fixes=%s err=%v", out.Fixes, err)
+	}
+}
@@ -0,0 +1,185 @@
+package deckcodec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ErrPackLocked is returned when a build would change a pack recorded in a PackLock.
+var ErrPackLocked = errors.New("deckcodec: pack conflicts with lock file")
+
+// PackLock records every published pack by format ID with a content hash,
+// so BuildPack and BuildManifestWithOpts can refuse to produce a different
+// pack under an existing format ID. Keep it in version control next to the
+// packs (e.g. packs.lock.json).
+//
+// The only accepted change to a locked pack is growing an append-only pack by
+// appending cards. Anything else needs Override, which is recorded in Overrides.
+type PackLock struct {
+	SchemaVersion int            `json:"schema_version"`
+	Packs         []LockEntry    `json:"packs"`               // ascending by format_id
+	Overrides     []LockOverride `json:"overrides,omitempty"` // audit log, oldest first
+}
+
+// LockEntry is the locked state of one published pack.
+type LockEntry struct {
+	FormatID   uint16 `json:"format_id"`
+	SHA256     string `json:"sha256"` // PackHash of the pack
+	M          int    `json:"M"`      // number of cards
+	AppendOnly bool   `json:"append_only,omitempty"`
+}
+
+// LockOverride records a forced change to a locked pack.
+type LockOverride struct {
+	FormatID uint16 `json:"format_id"`
+	Old      string `json:"old_sha256"`
+	New      string `json:"new_sha256"`
+	OldM     int    `json:"old_M"`
+	NewM     int    `json:"new_M"`
+	Reason   string `json:"reason"`
+	By       string `json:"by"`
+	At       string `json:"at"` // RFC3339
+}
+
+// PackHash returns the hex SHA-256 of everything that determines a pack's
+// codes: format ID, append-only flag and the cards in ordinal order.
+// Metadata such as Name or CreatedAt is not included.
+func PackHash(p Pack) string {
+	h := sha256.New()
+	var buf [8]byte
+	binary.LittleEndian.PutUint16(buf[:2], p.FormatID)
+	if p.AppendOnly {
+		buf[2] = 1
+	}
+	h.Write([]byte("deckcodec/pack/v1"))
+	h.Write(buf[:3])
+	for _, pk := range lockCards(p) {
+		binary.LittleEndian.PutUint64(buf[:], pk)
+		h.Write(buf[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lockCards returns the cards in ordinal order (sorted unless append-only).
+func lockCards(p Pack) []uint64 {
+	if p.AppendOnly || slices.IsSorted(p.Cards) {
+		return p.Cards
+	}
+	return slices.Sorted(slices.Values(p.Cards))
+}
+
+// entry returns the index of the entry for fid, and false if there is none.
+func (l *PackLock) entry(fid uint16) (int, bool) {
+	return slices.BinarySearchFunc(l.Packs, fid, func(e LockEntry, fid uint16) int {
+		return int(e.FormatID) - int(fid)
+	})
+}
+
+// Check reports whether p may be published under the lock: its format ID is
+// new, it matches the locked entry, or it is an append-only pack that only
+// appended cards. Conflicts are *PackError values matching ErrPackLocked.
+// A nil lock accepts every pack.
+func (l *PackLock) Check(p Pack) error {
+	if l == nil {
+		return nil
+	}
+	i, ok := l.entry(p.FormatID)
+	if !ok {
+		return nil
+	}
+	e := l.Packs[i]
+	got := PackHash(p)
+	if got == e.SHA256 {
+		return nil
+	}
+	conflict := func(reason string) error {
+		return &PackError{FormatID: p.FormatID, Reason: reason, Err: ErrPackLocked}
+	}
+	switch {
+	case e.AppendOnly != p.AppendOnly:
+		return conflict("append_only changed")
+	case !e.AppendOnly:
+		return conflict("content changed (locked sha256 " + e.SHA256[:min(12, len(e.SHA256))] + ", got " + got[:12] + ")")
+	case len(p.Cards) < e.M:
+		return conflict("append-only pack shrank from " + strconv.Itoa(e.M) + " to " + strconv.Itoa(len(p.Cards)) + " cards")
+	}
+	prefix := p
+	prefix.Cards = p.Cards[:e.M]
+	if PackHash(prefix) != e.SHA256 {
+		return conflict("append-only pack changed within its first " + strconv.Itoa(e.M) + " cards")
+	}
+	return nil
+}
+
+// Record checks p and stores its current state in the lock.
+func (l *PackLock) Record(p Pack) error {
+	if err := l.Check(p); err != nil {
+		return err
+	}
+	l.put(p)
+	return nil
+}
+
+// Override stores p in the lock even if it conflicts, and appends the change
+// to Overrides. Reason and by are required so every override is attributable.
+func (l *PackLock) Override(p Pack, reason, by string, at time.Time) error {
+	if reason == "" || by == "" {
+		return &PackError{FormatID: p.FormatID, Reason: "override needs a reason and an author", Err: ErrPackLocked}
+	}
+	o := LockOverride{FormatID: p.FormatID, New: PackHash(p), NewM: len(p.Cards), Reason: reason, By: by, At: at.UTC().Format(time.RFC3339)}
+	if i, ok := l.entry(p.FormatID); ok {
+		o.Old, o.OldM = l.Packs[i].SHA256, l.Packs[i].M
+	}
+	l.Overrides = append(l.Overrides, o)
+	l.put(p)
+	return nil
+}
+
+// put inserts or replaces the entry for p, keeping Packs sorted.
+func (l *PackLock) put(p Pack) {
+	e := LockEntry{FormatID: p.FormatID, SHA256: PackHash(p), M: len(p.Cards), AppendOnly: p.AppendOnly}
+	i, ok := l.entry(p.FormatID)
+	if ok {
+		l.Packs[i] = e
+		return
+	}
+	l.Packs = slices.Insert(l.Packs, i, e)
+}
+
+// ParsePackLock reads a lock file. An empty input is an empty lock.
+func ParsePackLock(r io.Reader) (*PackLock, error) {
+	var l PackLock
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil && err != io.EOF {
+		return nil, &PackError{Reason: "malformed lock file", Err: ErrInvalidPack, Cause: err}
+	}
+	slices.SortFunc(l.Packs, func(a, b LockEntry) int { return int(a.FormatID) - int(b.FormatID) })
+	for i := 1; i < len(l.Packs); i++ {
+		if l.Packs[i].FormatID == l.Packs[i-1].FormatID {
+			return nil, &PackError{FormatID: l.Packs[i].FormatID, Reason: "lock file", Err: ErrDuplicateFormatID}
+		}
+	}
+	return &l, nil
+}
+
+// WriteJSON writes the lock file as indented JSON.
+func (l *PackLock) WriteJSON(w io.Writer) error {
+	out := *l
+	if out.SchemaVersion == 0 {
+		out.SchemaVersion = 1
+	}
+	if out.Packs == nil {
+		out.Packs = []LockEntry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
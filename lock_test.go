@@ -0,0 +1,144 @@
+package deckcodec
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPackHash(t *testing.T) {
+	a := Pack{FormatID: 1, Name: "a", Cards: []uint64{3, 1, 2}}
+	b := Pack{FormatID: 1, Name: "b", Cards: []uint64{1, 2, 3}}
+	if PackHash(a) != PackHash(b) {
+		t.Fatal("hash depends on metadata or input order of a sorted pack")
+	}
+	if len(PackHash(a)) != 64 {
+		t.Fatalf("hash = %q", PackHash(a))
+	}
+	for name, p := range map[string]Pack{
+		"format id":   {FormatID: 2, Cards: b.Cards},
+		"append-only": {FormatID: 1, AppendOnly: true, Cards: b.Cards},
+		"cards":       {FormatID: 1, Cards: []uint64{1, 2, 4}},
+	} {
+		if PackHash(p) == PackHash(b) {
+			t.Errorf("%s: same hash", name)
+		}
+	}
+	// Append-only packs hash in insertion order.
+	if PackHash(Pack{FormatID: 1, AppendOnly: true, Cards: []uint64{3, 1}}) ==
+		PackHash(Pack{FormatID: 1, AppendOnly: true, Cards: []uint64{1, 3}}) {
+		t.Fatal("append-only hash ignores order")
+	}
+}
+
+func TestPackLock_BuildersEnforce(t *testing.T) {
+	lock := &PackLock{}
+	v1, err := BuildPack([]uint64{30, 10, 20}, PackBuildOpts{FormatID: 1, Lock: lock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a1, _ := BuildPack([]uint64{5, 9}, PackBuildOpts{FormatID: 2, AppendOnly: true})
+	for _, p := range []Pack{v1, a1} {
+		if err := lock.Record(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Identical rebuilds and append-only growth pass.
+	if _, err := BuildPack([]uint64{10, 20, 30}, PackBuildOpts{FormatID: 1, Name: "renamed", Lock: lock}); err != nil {
+		t.Fatalf("identical rebuild: %v", err)
+	}
+	a2, err := BuildPack([]uint64{5, 9, 7}, PackBuildOpts{FormatID: 2, AppendOnly: true, Lock: lock})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := lock.Record(a2); err != nil || lock.Packs[1].M != 3 {
+		t.Fatalf("Record(a2): %v, %+v", err, lock.Packs)
+	}
+
+	// Conflicting rebuilds fail.
+	for name, tc := range map[string]struct {
+		pks  []uint64
+		opts PackBuildOpts
+	}{
+		"added card":     {[]uint64{10, 20, 30, 40}, PackBuildOpts{FormatID: 1}},
+		"became append":  {[]uint64{10, 20, 30}, PackBuildOpts{FormatID: 1, AppendOnly: true}},
+		"reordered":      {[]uint64{9, 5, 7}, PackBuildOpts{FormatID: 2, AppendOnly: true}},
+		"shrunk":         {[]uint64{5, 9}, PackBuildOpts{FormatID: 2, AppendOnly: true}},
+		"changed suffix": {[]uint64{5, 9, 8}, PackBuildOpts{FormatID: 2, AppendOnly: true}},
+	} {
+		tc.opts.Lock = lock
+		_, err := BuildPack(tc.pks, tc.opts)
+		var pe *PackError
+		if !errors.Is(err, ErrPackLocked) || !errors.As(err, &pe) || pe.FormatID != tc.opts.FormatID {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+
+	urlFor := func(fid uint16) string { return "https://cdn/" + itoa(fid) }
+	changed := Pack{FormatID: 1, Cards: []uint64{10, 20}}
+	_, err = BuildManifestWithOpts([]Pack{a2, changed}, ManifestBuildOpts{URLFor: urlFor, Lock: lock})
+	if !errors.Is(err, ErrPackLocked) {
+		t.Fatalf("BuildManifestWithOpts: %v", err)
+	}
+	if _, err := BuildManifestWithOpts([]Pack{a2, v1}, ManifestBuildOpts{URLFor: urlFor, Lock: lock}); err != nil {
+		t.Fatalf("BuildManifestWithOpts locked packs: %v", err)
+	}
+}
+
+func TestPackLock_OverrideAndFile(t *testing.T) {
+	lock := &PackLock{}
+	v1 := Pack{FormatID: 4, Cards: []uint64{1, 2}}
+	_ = lock.Record(v1)
+	fixed := Pack{FormatID: 4, Cards: []uint64{1, 3}}
+	if err := lock.Record(fixed); !errors.Is(err, ErrPackLocked) {
+		t.Fatalf("Record conflicting: %v", err)
+	}
+	if err := lock.Override(fixed, "", "alice", time.Now()); !errors.Is(err, ErrPackLocked) {
+		t.Fatalf("Override without reason: %v", err)
+	}
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := lock.Override(fixed, "card 2 was never legal", "alice", at); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Check(fixed); err != nil {
+		t.Fatalf("Check after override: %v", err)
+	}
+	o := lock.Overrides[0]
+	if o.Old != PackHash(v1) || o.New != PackHash(fixed) || o.By != "alice" || o.At != "2025-03-01T12:00:00Z" {
+		t.Fatalf("override log = %+v", o)
+	}
+
+	_ = lock.Record(Pack{FormatID: 2, Cards: []uint64{7}})
+	var buf bytes.Buffer
+	if err := lock.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	back, err := ParsePackLock(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Packs) != 2 || back.Packs[0].FormatID != 2 || len(back.Overrides) != 1 || back.SchemaVersion != 1 {
+		t.Fatalf("round trip = %+v", back)
+	}
+	if err := back.Check(Pack{FormatID: 4, Cards: []uint64{1, 2}}); !errors.Is(err, ErrPackLocked) {
+		t.Fatalf("reverting to the overridden pack: %v", err)
+	}
+
+	if l, err := ParsePackLock(strings.NewReader("")); err != nil || len(l.Packs) != 0 {
+		t.Fatalf("empty lock file: %+v, %v", l, err)
+	}
+	dup := `{"schema_version":1,"packs":[{"format_id":1,"sha256":"x","M":1},{"format_id":1,"sha256":"y","M":1}]}`
+	if _, err := ParsePackLock(strings.NewReader(dup)); !errors.Is(err, ErrDuplicateFormatID) {
+		t.Fatalf("duplicate entry: %v", err)
+	}
+	if _, err := ParsePackLock(strings.NewReader(`{"packs":[],"extra":1}`)); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("unknown field: %v", err)
+	}
+	var nilLock *PackLock
+	if err := nilLock.Check(fixed); err != nil {
+		t.Fatalf("nil lock: %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package deckcodec
+
+const raceEnabled = false
@@ -0,0 +1,288 @@
+package deckcodec
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Argonauts-inc/deckcodec/bitstream"
+)
+
+// OrdinalCount is a main deck entry in pack-ordinal space.
+type OrdinalCount struct {
+	Ordinal uint32
+	Count   uint8 // 1..4
+}
+
+// Ordinals is a deck in pack-ordinal space: the ordinal of a card is its index
+// in Pack.Cards, and M is the pack size that fixes the ordinal width.
+// Sections are sorted ascending (the deck strictly, as each card appears once).
+//
+// Use EncodeOrdinals/DecodeOrdinals to work with codes without the pack, and
+// Resolve to map the result to PKs once the pack is available.
+type Ordinals struct {
+	FormatID uint16
+	M        int
+	Epoch    bool // the code records M (append-only packs)
+
+	// Fingerprint is the pack fingerprint carried by the code, if HasFingerprint.
+	// Resolve checks it against the pack.
+	Fingerprint    uint16
+	HasFingerprint bool
+
+	Leader  []uint32
+	Tactics []uint32
+	Deck    []OrdinalCount
+}
+
+// header returns the code header for o.
+func (o *Ordinals) header() header {
+	return header{fid: o.FormatID, m: o.M, epoch: o.Epoch, hasFP: o.HasFingerprint, fp: o.Fingerprint}
+}
+
+// offset returns the bit offset of ordinal i of sec in the code for o.
+func (o *Ordinals) offset(sec Section, i int) int {
+	ib := idBits(o.M)
+	base := o.header().bits()
+	switch sec {
+	case SectionLeader:
+		return base + 8 + i*ib
+	case SectionTactics:
+		return base + 16 + (len(o.Leader)+i)*ib
+	}
+	return base + 24 + (len(o.Leader)+len(o.Tactics))*ib + i*(ib+2)
+}
+
+// check validates o as Encode would have produced it.
+func (o *Ordinals) check() error {
+	if o.FormatID == 0 {
+		return &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
+	}
+	if o.M < 1 {
+		return &PackError{FormatID: o.FormatID, Reason: "M must be positive", Err: ErrInvalidPack}
+	}
+	// section checks the length, range and order of one section's ordinals.
+	section := func(sec Section, n int, at func(int) uint32) error {
+		if n > 255 {
+			return &SectionTooLongError{Section: sec, Len: n}
+		}
+		for i := range n {
+			v := at(i)
+			if int(v) >= o.M {
+				return &OrdinalRangeError{Section: sec, Offset: o.offset(sec, i), Ordinal: v, M: o.M}
+			}
+			if i > 0 && (v < at(i-1) || (sec == SectionDeck && v == at(i-1))) {
+				return &NonCanonicalError{Section: sec, Offset: o.offset(sec, i), Reason: "unsorted or duplicate ordinals"}
+			}
+		}
+		return nil
+	}
+	if err := section(SectionLeader, len(o.Leader), func(i int) uint32 { return o.Leader[i] }); err != nil {
+		return err
+	}
+	if err := section(SectionTactics, len(o.Tactics), func(i int) uint32 { return o.Tactics[i] }); err != nil {
+		return err
+	}
+	if err := section(SectionDeck, len(o.Deck), func(i int) uint32 { return o.Deck[i].Ordinal }); err != nil {
+		return err
+	}
+	for i, e := range o.Deck {
+		if e.Count < 1 || e.Count > 4 {
+			off := o.offset(SectionDeck, i) + idBits(o.M)
+			return &CountRangeError{Count: e.Count, Ordinal: e.Ordinal, Offset: off}
+		}
+	}
+	return nil
+}
+
+// EncodeOrdinals encodes a deck given in ordinal space. It produces the same
+// code as Encode for the corresponding PKs, without needing the pack itself.
+// Sections must be sorted (see Ordinals); unsorted input is rejected with a
+// *NonCanonicalError rather than reordered.
+func EncodeOrdinals(o Ordinals) (string, error) {
+	if err := o.check(); err != nil {
+		return "", err
+	}
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	P := sc.P[:0]
+	for _, e := range o.Deck {
+		P = append(P, pair{o: e.Ordinal, c: e.Count})
+	}
+	sc.P = P
+	sc.raw = appendSections(sc.raw[:0], o.header(), o.Leader, o.Tactics, P)
+	return base64.RawURLEncoding.EncodeToString(sc.raw), nil
+}
+
+// DecodeOrdinals decodes a code into ordinal space, given only the pack size m.
+// It checks that every ordinal is below m but not which PKs they stand for;
+// call Resolve once the pack is available. Sections are returned in code order,
+// which is sorted for every code Encode produces.
+// Codes for append-only packs record their own M; for those m is ignored.
+func DecodeOrdinals(code string, m int) (Ordinals, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return Ordinals{}, fmt.Errorf("%w: %w", ErrInvalidCode, err)
+	}
+	br := bitstream.NewReader(raw, len(raw)*8)
+	h, err := readHeader(&br, nil)
+	if err != nil {
+		return Ordinals{}, err
+	}
+	if h.epoch != 0 {
+		m = h.epoch
+	}
+	if m < 1 {
+		return Ordinals{}, &PackError{Reason: "M must be positive", Err: ErrInvalidPack}
+	}
+	if uint64(m) > maxEpoch {
+		return Ordinals{}, &PackError{Reason: "M exceeds the ordinal range", Err: ErrInvalidPack}
+	}
+	ib := idBits(m)
+
+	// Helper function to read a field, reporting truncation with its bit offset.
+	read := func(width int, sec Section) (uint64, error) {
+		off := br.Offset()
+		v, err := br.ReadBits(width)
+		if err != nil {
+			return 0, &TruncatedError{Section: sec, Offset: off}
+		}
+		return v, nil
+	}
+	// readOrd reads an ordinal and checks it against m.
+	readOrd := func(sec Section) (uint32, error) {
+		off := br.Offset()
+		v, err := read(ib, sec)
+		if err != nil {
+			return 0, err
+		}
+		if v >= uint64(m) {
+			return 0, &OrdinalRangeError{Section: sec, Offset: off, Ordinal: uint32(v), M: m}
+		}
+		return uint32(v), nil
+	}
+	// readSection reads an 8-bit count followed by that many ordinals.
+	readSection := func(sec Section) ([]uint32, error) {
+		n, err := read(8, sec)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]uint32, n)
+		for i := range out {
+			if out[i], err = readOrd(sec); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+
+	o := Ordinals{FormatID: h.fid, M: m, Epoch: h.epoch != 0, Fingerprint: h.fp, HasFingerprint: h.hasFP()}
+	if o.Leader, err = readSection(SectionLeader); err != nil {
+		return Ordinals{}, err
+	}
+	if o.Tactics, err = readSection(SectionTactics); err != nil {
+		return Ordinals{}, err
+	}
+	nD, err := read(8, SectionDeck)
+	if err != nil {
+		return Ordinals{}, err
+	}
+	o.Deck = make([]OrdinalCount, nD)
+	for i := range o.Deck {
+		ord, err := readOrd(SectionDeck)
+		if err != nil {
+			return Ordinals{}, err
+		}
+		cm1, err := read(2, SectionDeck)
+		if err != nil {
+			return Ordinals{}, err
+		}
+		o.Deck[i] = OrdinalCount{Ordinal: ord, Count: uint8(cm1) + 1}
+	}
+	return o, nil
+}
+
+// OrdinalsOf maps a deck to ordinal space for pack p, with sorted sections.
+func OrdinalsOf(p Pack, in DeckInput) (Ordinals, error) {
+	if err := checkEncodePack(p); err != nil {
+		return Ordinals{}, err
+	}
+	d := packDict(p)
+	sc := scratchPool.Get().(*scratch)
+	defer scratchPool.Put(sc)
+	if err := d.toOrdinals(in, sc); err != nil {
+		return Ordinals{}, err
+	}
+	o := Ordinals{
+		FormatID: p.FormatID,
+		M:        len(p.Cards),
+		Epoch:    p.AppendOnly,
+		Leader:   append([]uint32(nil), sc.L...),
+		Tactics:  append([]uint32(nil), sc.T...),
+		Deck:     make([]OrdinalCount, len(sc.P)),
+	}
+	for i, pr := range sc.P {
+		o.Deck[i] = OrdinalCount{Ordinal: pr.o, Count: pr.c}
+	}
+	return o, nil
+}
+
+// Resolve maps o to PKs using pack p, which must have o's format ID and size M
+// (or, for an append-only pack, at least M cards). Like Decode, it requires o
+// to record its epoch exactly when p is append-only.
+// The result is the same as Decode of the corresponding code.
+func (o Ordinals) Resolve(p Pack) (DeckOutput, error) {
+	if p.FormatID != o.FormatID {
+		return DeckOutput{}, &FormatMismatchError{Code: o.FormatID, Pack: p.FormatID}
+	}
+	if o.Epoch != p.AppendOnly || o.M < 1 || o.M > len(p.Cards) || (!o.Epoch && o.M != len(p.Cards)) {
+		return DeckOutput{}, &EpochError{FormatID: p.FormatID, Code: o.M, Pack: len(p.Cards), AppendOnly: p.AppendOnly}
+	}
+	if o.HasFingerprint {
+		if fp := fingerprint(p.FormatID, p.Cards[:o.M]); fp != o.Fingerprint {
+			return DeckOutput{}, &FingerprintError{FormatID: p.FormatID, Code: o.Fingerprint, Pack: fp}
+		}
+	}
+	// pk resolves one ordinal, reporting it at its bit offset in the code.
+	pk := func(sec Section, i int, v uint32) (uint64, error) {
+		if int(v) >= o.M {
+			return 0, &OrdinalRangeError{Section: sec, Offset: o.offset(sec, i), Ordinal: v, M: o.M}
+		}
+		return p.Cards[v], nil
+	}
+	out := DeckOutput{
+		FormatID: o.FormatID,
+		Leader:   make([]uint64, len(o.Leader)),
+		Tactics:  make([]uint64, len(o.Tactics)),
+		Deck:     make(map[uint64]uint8, len(o.Deck)),
+	}
+	var err error
+	for i, v := range o.Leader {
+		if out.Leader[i], err = pk(SectionLeader, i, v); err != nil {
+			return DeckOutput{}, err
+		}
+	}
+	for i, v := range o.Tactics {
+		if out.Tactics[i], err = pk(SectionTactics, i, v); err != nil {
+			return DeckOutput{}, err
+		}
+	}
+	for i, e := range o.Deck {
+		id, err := pk(SectionDeck, i, e.Ordinal)
+		if err != nil {
+			return DeckOutput{}, err
+		}
+		out.Deck[id] = e.Count
+	}
+	if len(p.Retired) > 0 {
+		for _, pk := range slices.Concat(out.Leader, out.Tactics, slices.Collect(maps.Keys(out.Deck))) {
+			if slices.Contains(p.Retired, pk) {
+				out.Retired = append(out.Retired, pk)
+			}
+		}
+		slices.Sort(out.Retired)
+		out.Retired = slices.Compact(out.Retired)
+	}
+	return out, nil
+}
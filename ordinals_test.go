@@ -0,0 +1,158 @@
+package deckcodec
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestOrdinals_RoundTrip(t *testing.T) {
+	p := testPack(42)
+	in := DeckInput{
+		Leader:  []uint64{1915, 101},
+		Tactics: []uint64{2117},
+		Deck:    map[uint64]uint8{205: 4, 1006: 1, 303: 2},
+	}
+	o, err := OrdinalsOf(p, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.M != len(p.Cards) || !slices.IsSorted(o.Leader) || len(o.Deck) != 3 {
+		t.Fatalf("OrdinalsOf = %+v", o)
+	}
+
+	// EncodeOrdinals must produce the same code as Encode.
+	want, err := Encode(p, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := EncodeOrdinals(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != want {
+		t.Fatalf("EncodeOrdinals = %q, Encode = %q", code, want)
+	}
+
+	// Decoding needs only M; resolving then matches Decode.
+	got, err := DecodeOrdinals(code, len(p.Cards))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.FormatID != 42 || !slices.Equal(got.Leader, o.Leader) || !slices.Equal(got.Deck, o.Deck) {
+		t.Fatalf("DecodeOrdinals = %+v, want %+v", got, o)
+	}
+	out, err := got.Resolve(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, _ := Decode(p, code)
+	if !equalUint64Slices(out.Leader, dec.Leader) || !equalUint64Slices(out.Tactics, dec.Tactics) ||
+		!equalDeckCounts(out.Deck, dec.Deck) || out.FormatID != dec.FormatID {
+		t.Fatalf("Resolve = %+v, Decode = %+v", out, dec)
+	}
+}
+
+func TestEncodeOrdinals_Errors(t *testing.T) {
+	base := func() Ordinals {
+		return Ordinals{FormatID: 7, M: 10, Leader: []uint32{1, 3}, Deck: []OrdinalCount{{2, 1}, {5, 4}}}
+	}
+	tests := []struct {
+		name   string
+		mutate func(*Ordinals)
+		want   error
+		offset int // expected bit offset (-1: not checked)
+	}{
+		{"zero format", func(o *Ordinals) { o.FormatID = 0 }, ErrInvalidPack, -1},
+		{"zero M", func(o *Ordinals) { o.M = 0 }, ErrInvalidPack, -1},
+		{"range", func(o *Ordinals) { o.Leader[1] = 10 }, ErrOrdinalRange, 24 + 4},
+		{"unsorted leader", func(o *Ordinals) { o.Leader = []uint32{3, 1} }, ErrNotCanonical, 24 + 4},
+		{"duplicate deck", func(o *Ordinals) { o.Deck[1].Ordinal = 2 }, ErrNotCanonical, 40 + 2*4 + 6},
+		{"count", func(o *Ordinals) { o.Deck[0].Count = 5 }, ErrCountRange, 40 + 2*4 + 4},
+		{"too long", func(o *Ordinals) { o.Tactics = make([]uint32, 256) }, ErrSectionTooLong, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := base()
+			tt.mutate(&o)
+			_, err := EncodeOrdinals(o)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.offset < 0 {
+				return
+			}
+			var re *OrdinalRangeError
+			var ne *NonCanonicalError
+			var ce *CountRangeError
+			switch {
+			case errors.As(err, &re) && re.Offset != tt.offset:
+				t.Fatalf("offset = %d, want %d", re.Offset, tt.offset)
+			case errors.As(err, &ne) && ne.Offset != tt.offset:
+				t.Fatalf("offset = %d, want %d", ne.Offset, tt.offset)
+			case errors.As(err, &ce) && (ce.Offset != tt.offset || ce.Ordinal != 2 || ce.Count != 5):
+				t.Fatalf("count error = %+v, want ordinal 2 count 5 at %d", ce, tt.offset)
+			}
+		})
+	}
+	// Leader and tactics may repeat a card, as with Encode.
+	o := base()
+	o.Leader = []uint32{3, 3}
+	if _, err := EncodeOrdinals(o); err != nil {
+		t.Fatalf("repeated leader: %v", err)
+	}
+}
+
+func TestDecodeOrdinals_Errors(t *testing.T) {
+	p := testPack(9)
+	code, err := Encode(p, DeckInput{Leader: []uint64{2117}, Deck: map[uint64]uint8{101: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2117 is the last card; a smaller M leaves its ordinal out of range.
+	if _, err := DecodeOrdinals(code, 17); !errors.Is(err, ErrOrdinalRange) {
+		t.Fatalf("small M: %v", err)
+	}
+	// A larger M widens the ordinals past the end of the code.
+	if _, err := DecodeOrdinals(code, 1<<20); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("large M: %v", err)
+	}
+	if _, err := DecodeOrdinals("!!", len(p.Cards)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("bad base64: %v", err)
+	}
+	if _, err := DecodeOrdinals(code, 0); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("zero M: %v", err)
+	}
+	// M above the ordinal range only fits where int is wider than 32 bits.
+	if math.MaxInt > maxEpoch {
+		if _, err := DecodeOrdinals(code, math.MaxInt); !errors.Is(err, ErrInvalidPack) {
+			t.Fatalf("M above the ordinal range: %v", err)
+		}
+	}
+
+	o, err := DecodeOrdinals(code, len(p.Cards))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Resolve(testPack(8)); !errors.Is(err, ErrFormatMismatch) {
+		t.Fatalf("Resolve other format: %v", err)
+	}
+	short := Pack{FormatID: 9, Cards: p.Cards[:len(p.Cards)-1]}
+	if _, err := o.Resolve(short); !errors.Is(err, ErrFormatMismatch) {
+		t.Fatalf("Resolve other M: %v", err)
+	}
+
+	// Without an epoch, M must match an append-only pack exactly: Decode of
+	// that code reads len(Cards) ordinals.
+	ao := Pack{FormatID: 9, Cards: p.Cards, AppendOnly: true}
+	if _, err := (Ordinals{FormatID: 9, M: len(p.Cards) - 1}).Resolve(ao); !errors.Is(err, ErrFormatMismatch) {
+		t.Fatalf("Resolve append-only pack without epoch: %v", err)
+	}
+	// An epoch never resolves with a sorted pack, even when M matches.
+	withEpoch := o
+	withEpoch.Epoch = true
+	if _, err := withEpoch.Resolve(p); !errors.Is(err, ErrFormatMismatch) {
+		t.Fatalf("Resolve sorted pack with epoch: %v", err)
+	}
+}
@@ -1,57 +1,275 @@
 package deckcodec
 
 import (
+	"bufio"
+	"cmp"
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
-	"errors"
 	"hash/fnv"
 	"io"
 	"math"
 	"slices"
+	"strconv"
 	"time"
 )
 
 // Pack represents a dictionary of card primary keys for a given format.
 // Cards MUST be ascending for ordinal-based encoding to be stable.
+//
+// An append-only pack keeps Cards in insertion order instead: ordinals are
+// positions in that order, new cards may only be appended, and codes record
+// the pack size (epoch) they were encoded against, so a grown pack still
+// decodes older codes exactly under the same format ID.
 type Pack struct {
 	FormatID      uint16   `json:"format_id"`
 	Name          string   `json:"name,omitempty"`
 	CreatedAt     string   `json:"created_at,omitempty"`
 	SchemaVersion int      `json:"schema_version,omitempty"`
+	AppendOnly    bool     `json:"append_only,omitempty"`
 	Cards         []uint64 `json:"cards"`
+
+	// Reprints declares reprint equivalence classes (see ReprintClass).
+	// BuildPack, ParsePack and NewCodec validate them; Encode trusts them.
+	Reprints []ReprintClass `json:"reprints,omitempty"`
+	// Retired lists tombstoned cards: they must be in Cards and keep their
+	// ordinal, so existing codes still decode (DeckOutput.Retired flags them),
+	// but Encode rejects them unless EncodeOpts.AllowRetired is set. Like
+	// Reprints, they are validated once rather than by every Encode.
+	Retired []uint64 `json:"retired,omitempty"`
+
+	// CompactCards makes MarshalJSON write Cards as cards_b64, the Base64URL
+	// delta-varint card list (see packbin.go), instead of a decimal array.
+	// Unmarshaling sets it when the input used cards_b64.
+	CompactCards bool `json:"-"`
+
+	// byPK lists the ordinals of an append-only pack in ascending PK order, so
+	// Encode can binary-search it. The constructors (BuildPack, AppendPack,
+	// ParsePack, UnmarshalJSON, UnmarshalBinary) set it; without it Encode
+	// scans Cards.
+	byPK []uint32
+}
+
+// packAlias has Pack's fields without its methods.
+type packAlias Pack
+
+// packJSON is the JSON form of a Pack with either cards or cards_b64.
+type packJSON struct {
+	FormatID      uint16         `json:"format_id"`
+	Name          string         `json:"name,omitempty"`
+	CreatedAt     string         `json:"created_at,omitempty"`
+	SchemaVersion int            `json:"schema_version,omitempty"`
+	AppendOnly    bool           `json:"append_only,omitempty"`
+	Cards         []uint64       `json:"cards,omitempty"`
+	CardsB64      string         `json:"cards_b64,omitempty"`
+	Reprints      []ReprintClass `json:"reprints,omitempty"`
+	Retired       []uint64       `json:"retired,omitempty"`
+}
+
+// MarshalJSON writes p with a cards array, or with cards_b64 if CompactCards is set.
+func (p Pack) MarshalJSON() ([]byte, error) {
+	if !p.CompactCards {
+		return json.Marshal(packAlias(p))
+	}
+	b64, err := encodeCardsB64(p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(packJSON{
+		FormatID:      p.FormatID,
+		Name:          p.Name,
+		CreatedAt:     p.CreatedAt,
+		SchemaVersion: p.SchemaVersion,
+		AppendOnly:    p.AppendOnly,
+		CardsB64:      b64,
+		Reprints:      p.Reprints,
+		Retired:       p.Retired,
+	})
+}
+
+// UnmarshalJSON reads a pack with either a cards array or cards_b64.
+func (p *Pack) UnmarshalJSON(data []byte) error {
+	var j packJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	return p.fromJSON(j)
+}
+
+// fromJSON sets p from its JSON form, decoding cards_b64 if present.
+func (p *Pack) fromJSON(j packJSON) error {
+	out := Pack{
+		FormatID:      j.FormatID,
+		Name:          j.Name,
+		CreatedAt:     j.CreatedAt,
+		SchemaVersion: j.SchemaVersion,
+		AppendOnly:    j.AppendOnly,
+		Cards:         j.Cards,
+		Reprints:      j.Reprints,
+		Retired:       j.Retired,
+	}
+	if j.CardsB64 != "" {
+		if j.Cards != nil {
+			return &PackError{FormatID: j.FormatID, Reason: "both cards and cards_b64 set", Err: ErrInvalidPack}
+		}
+		cards, err := decodeCardsB64(j.CardsB64, j.AppendOnly)
+		if err != nil {
+			return &PackError{FormatID: j.FormatID, Reason: "malformed cards_b64", Err: ErrInvalidPack, Cause: err}
+		}
+		out.Cards, out.CompactCards = cards, true
+	}
+	if out.AppendOnly {
+		out.byPK = pkOrder(out.Cards)
+	}
+	*p = out
+	return nil
 }
 
 type PackBuildOpts struct {
 	FormatID    uint16
 	Name        string
 	Deduplicate bool // default: true; remove duplicate card ids
+
+	// AppendOnly builds an append-only pack: PKs keep their given order
+	// (duplicates are always dropped, keeping the first occurrence).
+	AppendOnly bool
+	// Previous is the last published version of an append-only pack.
+	// BuildPack fails unless the new card list starts with Previous.Cards.
+	Previous *Pack
+	// Lock, if set, makes BuildPack fail with ErrPackLocked when the result
+	// conflicts with the locked pack of the same format ID (see PackLock.Check).
+	// BuildPack does not record the result; call Lock.Record once it is published.
+	Lock *PackLock
+	// CompactCards sets Pack.CompactCards, so the pack's JSON carries cards_b64
+	// instead of a decimal cards array.
+	CompactCards bool
+	// Reprints sets Pack.Reprints. Each canonical card must be among the PKs.
+	Reprints []ReprintClass
+	// Retired sets Pack.Retired. Each retired card must be among the PKs.
+	Retired []uint64
 }
 
 // BuildPack builds a Pack from an in-memory list of PKs.
-// It sorts ascending and (optionally) de-duplicates.
+// It sorts ascending and (optionally) de-duplicates, or for append-only packs
+// keeps the given order and checks it against opts.Previous.
 func BuildPack(pks []uint64, opts PackBuildOpts) (Pack, error) {
 	if opts.FormatID == 0 {
-		return Pack{}, errors.New("deckcodec: FormatID must be non-zero")
+		return Pack{}, &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
 	}
 	if len(pks) == 0 {
-		return Pack{}, errors.New("deckcodec: no card PKs provided")
+		return Pack{}, &PackError{FormatID: opts.FormatID, Reason: "no card PKs provided", Err: ErrInvalidPack}
 	}
-	// Defensive copy
-	cards := slices.Clone(pks)
-	// Sort ascending
-	slices.Sort(cards)
-	// De-duplicate (recommended for stable ordinals)
-	if opts.Deduplicate {
-		cards = dedupSorted(cards)
+	var p Pack
+	if opts.AppendOnly || opts.Previous != nil {
+		var err error
+		if p, err = buildAppendOnly(pks, opts); err != nil {
+			return Pack{}, err
+		}
+	} else {
+		// Defensive copy
+		cards := slices.Clone(pks)
+		// Sort ascending
+		slices.Sort(cards)
+		// De-duplicate (recommended for stable ordinals)
+		if opts.Deduplicate {
+			cards = dedupSorted(cards)
+		}
+		p = Pack{
+			FormatID: opts.FormatID,
+			Name:     opts.Name,
+			Cards:    cards,
+		}
+	}
+	p.CompactCards = opts.CompactCards
+	p.Reprints = cloneReprints(opts.Reprints)
+	p.Retired = slices.Clone(opts.Retired)
+	if err := checkReprints(p); err != nil {
+		return Pack{}, err
+	}
+	if err := checkRetired(p); err != nil {
+		return Pack{}, err
+	}
+	if err := opts.Lock.Check(p); err != nil {
+		return Pack{}, err
+	}
+	return p, nil
+}
+
+// buildAppendOnly builds an append-only pack, refusing any change to
+// opts.Previous other than appending cards.
+func buildAppendOnly(pks []uint64, opts PackBuildOpts) (Pack, error) {
+	cards := dedupStable(pks)
+	if prev := opts.Previous; prev != nil {
+		switch {
+		case !prev.AppendOnly:
+			return Pack{}, &PackError{FormatID: opts.FormatID, Reason: "previous pack is not append-only", Err: ErrInvalidPack}
+		case prev.FormatID != opts.FormatID:
+			return Pack{}, &PackError{FormatID: opts.FormatID, Reason: "previous pack has format_id " + strconv.Itoa(int(prev.FormatID)), Err: ErrInvalidPack}
+		case len(cards) < len(prev.Cards):
+			return Pack{}, &PackError{FormatID: opts.FormatID, Reason: "append-only pack cannot shrink (" +
+				strconv.Itoa(len(prev.Cards)) + " → " + strconv.Itoa(len(cards)) + " cards)", Err: ErrInvalidPack}
+		}
+		for i, pk := range prev.Cards {
+			if cards[i] != pk {
+				return Pack{}, &PackError{FormatID: opts.FormatID, Reason: "append-only pack changed at ordinal " +
+					strconv.Itoa(i) + " (pk " + strconv.FormatUint(pk, 10) + " → " + strconv.FormatUint(cards[i], 10) + ")", Err: ErrInvalidPack}
+			}
+		}
 	}
 	return Pack{
-		FormatID: opts.FormatID,
-		Name:     opts.Name,
-		Cards:    cards,
+		FormatID:   opts.FormatID,
+		Name:       opts.Name,
+		AppendOnly: true,
+		Cards:      cards,
+		byPK:       pkOrder(cards),
 	}, nil
 }
 
+// AppendPack returns a new version of the append-only pack prev with the PKs
+// not yet in it appended in the given order. prev is not modified.
+func AppendPack(prev Pack, pks []uint64) (Pack, error) {
+	p, err := BuildPack(append(slices.Clone(prev.Cards), pks...), PackBuildOpts{
+		FormatID:     prev.FormatID,
+		Name:         prev.Name,
+		Previous:     &prev,
+		CompactCards: prev.CompactCards,
+		Reprints:     prev.Reprints,
+		Retired:      prev.Retired,
+	})
+	if err != nil {
+		return Pack{}, err
+	}
+	p.SchemaVersion = prev.SchemaVersion
+	return p, nil
+}
+
+// pkOrder returns the ordinals of cards sorted by PK (see Pack.byPK). Repeated
+// PKs keep ordinal order, so a search finds the first, as a scan would.
+func pkOrder(cards []uint64) []uint32 {
+	order := make([]uint32, len(cards))
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	slices.SortFunc(order, func(a, b uint32) int {
+		return cmp.Or(cmp.Compare(cards[a], cards[b]), cmp.Compare(a, b))
+	})
+	return order
+}
+
+// dedupStable returns a copy of a without repeated PKs, keeping first occurrences in order.
+func dedupStable(a []uint64) []uint64 {
+	seen := make(map[uint64]struct{}, len(a))
+	out := make([]uint64, 0, len(a))
+	for _, pk := range a {
+		if _, dup := seen[pk]; !dup {
+			seen[pk] = struct{}{}
+			out = append(out, pk)
+		}
+	}
+	return out
+}
+
 func dedupSorted(a []uint64) []uint64 {
 	if len(a) <= 1 {
 		return a
@@ -66,16 +284,62 @@ func dedupSorted(a []uint64) []uint64 {
 }
 
 // ParsePack reads a Pack from any io.Reader (file, HTTP, memory buffer).
-// It validates FormatID and sorts Cards ascending for stable ordinals.
+// Gzip-compressed input is detected and decompressed, and cards may be given
+// as a cards array or as cards_b64.
+// It validates FormatID and sorts Cards ascending for stable ordinals;
+// append-only packs keep their order and must not repeat a PK.
+// Use ParsePackWithOpts with Strict to reject anything ValidatePack reports.
 func ParsePack(r io.Reader) (Pack, error) {
-	var p Pack
+	p, err := readPackJSON(r)
+	if err != nil {
+		return Pack{}, err
+	}
+	return finishPack(p)
+}
+
+// readPackJSON decodes pack JSON, gunzipping it first if needed, and returns
+// the pack as written.
+func readPackJSON(r io.Reader) (Pack, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return Pack{}, &PackError{Reason: "malformed gzip", Err: ErrInvalidPack, Cause: err}
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+	var j packJSON
 	dec := json.NewDecoder(r)
 	dec.DisallowUnknownFields()
-	if err := dec.Decode(&p); err != nil {
+	if err := dec.Decode(&j); err != nil {
+		return Pack{}, &PackError{Reason: "malformed pack JSON", Err: ErrInvalidPack, Cause: err}
+	}
+	var p Pack
+	if err := p.fromJSON(j); err != nil {
 		return Pack{}, err
 	}
+	return p, nil
+}
+
+// finishPack applies ParsePack's checks and sorting to a pack as read.
+func finishPack(p Pack) (Pack, error) {
 	if p.FormatID == 0 {
-		return Pack{}, errorsNew("deckcodec: pack.FormatID must be non-zero")
+		return Pack{}, &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
+	}
+	if err := checkReprints(p); err != nil {
+		return Pack{}, err
+	}
+	if err := checkRetired(p); err != nil {
+		return Pack{}, err
+	}
+	if p.AppendOnly {
+		if len(dedupStable(p.Cards)) != len(p.Cards) {
+			return Pack{}, &PackError{FormatID: p.FormatID, Reason: "duplicate card PK in append-only pack", Err: ErrInvalidPack}
+		}
+		return p, nil
 	}
 	// Safety: keep cards ascending
 	slices.Sort(p.Cards)
@@ -131,11 +395,22 @@ func (b *BloomMeta) MayContain(pk uint64) bool {
 // PackMeta summarizes one pack for the manifest.
 // Bloom is optional and present only if targetFP > 0 when building the manifest.
 type PackMeta struct {
-	FormatID uint16     `json:"format_id"`
-	Name     string     `json:"name,omitempty"`
-	URL      string     `json:"url"` // absolute or CDN path to pack JSON
-	M        int        `json:"M"`   // number of cards in the pack
-	Bloom    *BloomMeta `json:"bloom,omitempty"`
+	FormatID   uint16     `json:"format_id"`
+	Name       string     `json:"name,omitempty"`
+	URL        string     `json:"url"` // absolute or CDN path to pack JSON
+	M          int        `json:"M"`   // number of cards in the pack
+	AppendOnly bool       `json:"append_only,omitempty"`
+	Bloom      *BloomMeta `json:"bloom,omitempty"`
+}
+
+// ManifestBuildOpts are the parameters of BuildManifestWithOpts.
+type ManifestBuildOpts struct {
+	URLFor        func(fid uint16) string // must return a non-empty URL for each pack
+	SchemaVersion int
+	UpdatedAt     time.Time
+	TargetFP      float64 // Bloom false-positive rate; 0 disables Bloom filters
+	// Lock, if set, rejects any pack that conflicts with it (see PackLock.Check).
+	Lock *PackLock
 }
 
 // BuildManifest builds a manifest. If targetFP > 0, it attaches a Bloom filter
@@ -151,37 +426,55 @@ func BuildManifest(
 	updatedAt time.Time,
 	targetFP float64,
 ) (Manifest, error) {
+	return BuildManifestWithOpts(packs, ManifestBuildOpts{
+		URLFor:        urlFor,
+		SchemaVersion: schemaVersion,
+		UpdatedAt:     updatedAt,
+		TargetFP:      targetFP,
+	})
+}
+
+// BuildManifestWithOpts is BuildManifest with its parameters in a struct,
+// plus lock file enforcement.
+func BuildManifestWithOpts(packs []Pack, opts ManifestBuildOpts) (Manifest, error) {
+	urlFor, targetFP := opts.URLFor, opts.TargetFP
 	if len(packs) == 0 {
-		return Manifest{}, errorsNew("deckcodec: no packs to build manifest")
+		return Manifest{}, &PackError{Reason: "no packs to build manifest", Err: ErrInvalidPack}
 	}
 	seen := make(map[uint16]struct{}, len(packs))
 	metas := make([]PackMeta, 0, len(packs))
 
 	for _, p := range packs {
 		if p.FormatID == 0 {
-			return Manifest{}, errorsNew("deckcodec: pack.FormatID must be non-zero")
+			return Manifest{}, &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
 		}
 		if _, dup := seen[p.FormatID]; dup {
-			return Manifest{}, errorsNew("deckcodec: duplicate format_id in packs")
+			return Manifest{}, &PackError{FormatID: p.FormatID, Err: ErrDuplicateFormatID}
 		}
 		seen[p.FormatID] = struct{}{}
+		if err := opts.Lock.Check(p); err != nil {
+			return Manifest{}, err
+		}
 
-		// Safety: ensure ascending card order
-		slices.Sort(p.Cards)
+		// Safety: ensure ascending card order (append-only packs keep theirs)
+		if !p.AppendOnly {
+			slices.Sort(p.Cards)
+		}
 
 		u := ""
 		if urlFor != nil {
 			u = urlFor(p.FormatID)
 		}
 		if u == "" {
-			return Manifest{}, errorsNew("deckcodec: urlFor returned empty URL")
+			return Manifest{}, &PackError{FormatID: p.FormatID, Err: ErrMissingURL}
 		}
 
 		pm := PackMeta{
-			FormatID: p.FormatID,
-			Name:     p.Name,
-			URL:      u,
-			M:        len(p.Cards),
+			FormatID:   p.FormatID,
+			Name:       p.Name,
+			URL:        u,
+			M:          len(p.Cards),
+			AppendOnly: p.AppendOnly,
 		}
 
 		// Optional Bloom
@@ -214,21 +507,14 @@ func BuildManifest(
 	})
 
 	return Manifest{
-		SchemaVersion: schemaVersion,
-		UpdatedAt:     updatedAt.UTC().Format(time.RFC3339),
+		SchemaVersion: opts.SchemaVersion,
+		UpdatedAt:     opts.UpdatedAt.UTC().Format(time.RFC3339),
 		Packs:         metas,
 	}, nil
 }
 
 // --- internal helpers ---
 
-// tiny local error helper (to avoid importing "errors" here)
-func errorsNew(s string) error { return &simpleError{s: s} }
-
-type simpleError struct{ s string }
-
-func (e *simpleError) Error() string { return e.s }
-
 // buildBloomForCards constructs a Bloom filter for the given card PKs.
 func buildBloomForCards(cards []uint64, targetFP float64) (*BloomMeta, error) {
 	n := len(cards)
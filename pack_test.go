@@ -2,7 +2,9 @@ package deckcodec
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"slices"
 	"testing"
 	"time"
@@ -55,6 +57,69 @@ func TestParsePack_Errors(t *testing.T) {
 	}
 }
 
+// TestParsePack_Gzip verifies that gzip-compressed pack JSON is detected.
+func TestParsePack_Gzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"format_id":7,"cards":[5,3,7]}`))
+	zw.Close()
+	p, err := ParsePack(&buf)
+	if err != nil {
+		t.Fatalf("ParsePack error: %v", err)
+	}
+	if p.FormatID != 7 || !slices.Equal(p.Cards, []uint64{3, 5, 7}) {
+		t.Fatalf("got %+v", p)
+	}
+	// Gzip magic with a broken stream
+	if _, err := ParsePack(bytes.NewReader([]byte{0x1f, 0x8b, 0})); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("broken gzip: got %v", err)
+	}
+}
+
+// TestParsePack_CardsB64 verifies that BuildPack can emit cards_b64 and that
+// ParsePack reads it back with the same ordinals, for sorted and append-only packs.
+func TestParsePack_CardsB64(t *testing.T) {
+	for _, ao := range []bool{false, true} {
+		p, err := BuildPack([]uint64{900, 5, 1 << 62, 70000}, PackBuildOpts{FormatID: 9, AppendOnly: ao, CompactCards: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(b, []byte(`"cards"`)) || !bytes.Contains(b, []byte(`"cards_b64"`)) {
+			t.Fatalf("append_only=%v: want cards_b64 only, got %s", ao, b)
+		}
+		got, err := ParsePack(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("append_only=%v: ParsePack: %v", ao, err)
+		}
+		if !slices.Equal(got.Cards, p.Cards) || got.AppendOnly != ao || !got.CompactCards {
+			t.Fatalf("append_only=%v: got %+v want %+v", ao, got, p)
+		}
+	}
+
+	// Without CompactCards the JSON keeps the plain cards array.
+	p, _ := BuildPack([]uint64{2, 1}, PackBuildOpts{FormatID: 1})
+	if b, _ := json.Marshal(p); string(b) != `{"format_id":1,"cards":[1,2]}` {
+		t.Fatalf("plain JSON changed: %s", b)
+	}
+}
+
+// TestParsePack_CardsB64Errors checks invalid cards_b64 input.
+func TestParsePack_CardsB64Errors(t *testing.T) {
+	for name, src := range map[string]string{
+		"both":      `{"format_id":1,"cards":[1],"cards_b64":"AQ"}`,
+		"base64":    `{"format_id":1,"cards_b64":"!!"}`,
+		"truncated": `{"format_id":1,"cards_b64":"gA"}`, // 0x80: unterminated varint
+	} {
+		if _, err := ParsePack(bytes.NewBufferString(src)); !errors.Is(err, ErrInvalidPack) {
+			t.Errorf("%s: want ErrInvalidPack, got %v", name, err)
+		}
+	}
+}
+
 //
 // ----------------------- BuildManifest tests ----------------------
 //
@@ -0,0 +1,332 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strconv"
+)
+
+// Binary pack format (version 1). All integers are little-endian; varints
+// are encoding/binary uvarints.
+//
+//	magic      4 bytes  "DCPK"
+//	version    1 byte   1
+//	flags      1 byte   bit 0: append-only
+//	format_id  2 bytes
+//	M          uvarint  number of cards
+//	sections   (tag byte, uvarint length, payload)*, terminated by tag 0
+//	cards      M varints in ordinal order, delta-coded from 0:
+//	           uvarint deltas for sorted packs, zigzag varint deltas for append-only packs
+//	crc32      4 bytes  IEEE CRC-32 of everything before it
+//
+// Sections hold the optional metadata. Readers skip unknown tags, so new
+// metadata can be added without a version bump.
+const (
+	packMagic      = "DCPK"
+	packBinVersion = 1
+
+	packFlagAppendOnly = 1 << 0
+
+	tagEnd           = 0
+	tagName          = 1
+	tagCreatedAt     = 2
+	tagSchemaVersion = 3 // uvarint
+	tagReprints      = 4 // uvarint class count, then per class: canonical, printing count, printings (uvarints)
+	tagRetired       = 5 // uvarint PKs
+)
+
+// MarshalBinary encodes p in the binary pack format. The cards are written in
+// ordinal order, so the result decodes to the same ordinals. Sorted packs must
+// have ascending Cards.
+func (p Pack) MarshalBinary() ([]byte, error) {
+	if p.FormatID == 0 {
+		return nil, &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
+	}
+	b := make([]byte, 0, 32+len(p.Name)+len(p.CreatedAt)+2*len(p.Cards))
+	b = append(b, packMagic...)
+	b = append(b, packBinVersion)
+	var flags byte
+	if p.AppendOnly {
+		flags |= packFlagAppendOnly
+	}
+	b = append(b, flags)
+	b = binary.LittleEndian.AppendUint16(b, p.FormatID)
+	b = binary.AppendUvarint(b, uint64(len(p.Cards)))
+
+	section := func(tag byte, payload []byte) {
+		b = append(b, tag)
+		b = binary.AppendUvarint(b, uint64(len(payload)))
+		b = append(b, payload...)
+	}
+	if p.Name != "" {
+		section(tagName, []byte(p.Name))
+	}
+	if p.CreatedAt != "" {
+		section(tagCreatedAt, []byte(p.CreatedAt))
+	}
+	if p.SchemaVersion > 0 {
+		section(tagSchemaVersion, binary.AppendUvarint(nil, uint64(p.SchemaVersion)))
+	}
+	if len(p.Reprints) > 0 {
+		var r []byte
+		r = binary.AppendUvarint(r, uint64(len(p.Reprints)))
+		for _, rc := range p.Reprints {
+			r = binary.AppendUvarint(r, rc.Canonical)
+			r = binary.AppendUvarint(r, uint64(len(rc.Printings)))
+			for _, pk := range rc.Printings {
+				r = binary.AppendUvarint(r, pk)
+			}
+		}
+		section(tagReprints, r)
+	}
+	if len(p.Retired) > 0 {
+		var r []byte
+		for _, pk := range p.Retired {
+			r = binary.AppendUvarint(r, pk)
+		}
+		section(tagRetired, r)
+	}
+	b = append(b, tagEnd)
+
+	var err error
+	if b, err = appendCards(b, p); err != nil {
+		return nil, err
+	}
+	return binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b)), nil
+}
+
+// appendCards appends the delta-coded card list of p to b.
+func appendCards(b []byte, p Pack) ([]byte, error) {
+	var prev uint64
+	for i, pk := range p.Cards {
+		if p.AppendOnly {
+			b = binary.AppendVarint(b, int64(pk-prev))
+		} else {
+			if pk < prev {
+				return nil, &PackError{FormatID: p.FormatID, Reason: "cards not sorted at ordinal " + strconv.Itoa(i), Err: ErrInvalidPack}
+			}
+			b = binary.AppendUvarint(b, pk-prev)
+		}
+		prev = pk
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a pack written by MarshalBinary. Cards keep the
+// stored order, so ordinals are exactly those of the encoded pack.
+func (p *Pack) UnmarshalBinary(data []byte) error {
+	bad := func(reason string) error {
+		return &PackError{Reason: "binary pack: " + reason, Err: ErrInvalidPack}
+	}
+	if len(data) < len(packMagic)+2+2+1+1+4 || string(data[:len(packMagic)]) != packMagic {
+		return bad("bad magic")
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return bad("checksum mismatch")
+	}
+	r := body[len(packMagic):]
+	if r[0] != packBinVersion {
+		return bad("unsupported version " + strconv.Itoa(int(r[0])))
+	}
+	flags := r[1]
+	fid := binary.LittleEndian.Uint16(r[2:4])
+	r = r[4:]
+
+	uvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(r)
+		if n <= 0 {
+			return 0, false
+		}
+		r = r[n:]
+		return v, true
+	}
+	m, ok := uvarint()
+	// Every card takes at least one byte, which bounds M before allocating.
+	if !ok || m > uint64(len(r)) {
+		return bad("bad card count")
+	}
+
+	out := Pack{FormatID: fid, AppendOnly: flags&packFlagAppendOnly != 0}
+	for {
+		if len(r) == 0 {
+			return bad("truncated sections")
+		}
+		tag := r[0]
+		r = r[1:]
+		if tag == tagEnd {
+			break
+		}
+		n, ok := uvarint()
+		if !ok || n > uint64(len(r)) {
+			return bad("truncated section " + strconv.Itoa(int(tag)))
+		}
+		payload := r[:n]
+		r = r[n:]
+		switch tag {
+		case tagName:
+			out.Name = string(payload)
+		case tagCreatedAt:
+			out.CreatedAt = string(payload)
+		case tagSchemaVersion:
+			v, k := binary.Uvarint(payload)
+			if k <= 0 || v > 1<<31 {
+				return bad("bad schema_version")
+			}
+			out.SchemaVersion = int(v)
+		case tagReprints:
+			rs, err := readReprints(payload)
+			if err != nil {
+				return bad(err.Error())
+			}
+			out.Reprints = rs
+		case tagRetired:
+			for len(payload) > 0 {
+				pk, k := binary.Uvarint(payload)
+				if k <= 0 {
+					return bad("bad retired section")
+				}
+				out.Retired = append(out.Retired, pk)
+				payload = payload[k:]
+			}
+		}
+	}
+
+	cards, err := readCards(r, int(m), out.AppendOnly)
+	if err != nil {
+		return bad(err.Error())
+	}
+	out.Cards = cards
+	if out.AppendOnly {
+		out.byPK = pkOrder(cards)
+	}
+	*p = out
+	return nil
+}
+
+// readCards decodes exactly m delta-coded cards filling all of r.
+func readCards(r []byte, m int, appendOnly bool) ([]uint64, error) {
+	cards := make([]uint64, m)
+	var prev uint64
+	for i := range cards {
+		var n int
+		if appendOnly {
+			var d int64
+			d, n = binary.Varint(r)
+			prev += uint64(d)
+		} else {
+			var d uint64
+			d, n = binary.Uvarint(r)
+			if prev+d < prev {
+				return nil, errors.New("card delta overflows")
+			}
+			prev += d
+		}
+		if n <= 0 {
+			return nil, errors.New("truncated cards")
+		}
+		r = r[n:]
+		cards[i] = prev
+	}
+	if len(r) != 0 {
+		return nil, errors.New("trailing bytes")
+	}
+	return cards, nil
+}
+
+// encodeCardsB64 returns the cards_b64 form of p's cards: the delta-coded
+// card list of the binary format, Base64URL without padding.
+func encodeCardsB64(p Pack) (string, error) {
+	b, err := appendCards(nil, p)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCardsB64 decodes a cards_b64 value. Each varint ends in the only one
+// of its bytes below 0x80, which gives the card count.
+func decodeCardsB64(s string, appendOnly bool) ([]uint64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	m := 0
+	for _, c := range b {
+		if c < 0x80 {
+			m++
+		}
+	}
+	return readCards(b, m, appendOnly)
+}
+
+// readReprints decodes a tagReprints section payload.
+func readReprints(b []byte) ([]ReprintClass, error) {
+	errBad := errors.New("bad reprints section")
+	uvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, false
+		}
+		b = b[n:]
+		return v, true
+	}
+	// Every count and PK takes at least one byte, which bounds the counts.
+	n, ok := uvarint()
+	if !ok || n > uint64(len(b)) {
+		return nil, errBad
+	}
+	out := make([]ReprintClass, n)
+	for i := range out {
+		c, ok1 := uvarint()
+		k, ok2 := uvarint()
+		if !ok1 || !ok2 || k > uint64(len(b)) {
+			return nil, errBad
+		}
+		out[i] = ReprintClass{Canonical: c, Printings: make([]uint64, k)}
+		for j := range out[i].Printings {
+			if out[i].Printings[j], ok = uvarint(); !ok {
+				return nil, errBad
+			}
+		}
+	}
+	if len(b) != 0 {
+		return nil, errBad
+	}
+	return out, nil
+}
+
+// ParsePackBinary reads a binary pack (see MarshalBinary) from r and validates
+// it like ParsePack. Cards keep their stored order.
+func ParsePackBinary(r io.Reader) (Pack, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Pack{}, &PackError{Reason: "read binary pack", Err: ErrInvalidPack, Cause: err}
+	}
+	var p Pack
+	if err := p.UnmarshalBinary(data); err != nil {
+		return Pack{}, err
+	}
+	if p.FormatID == 0 {
+		return Pack{}, &PackError{Reason: "FormatID must be non-zero", Err: ErrInvalidPack}
+	}
+	if p.AppendOnly && len(dedupStable(p.Cards)) != len(p.Cards) {
+		return Pack{}, &PackError{FormatID: p.FormatID, Reason: "duplicate card PK in append-only pack", Err: ErrInvalidPack}
+	}
+	if err := checkReprints(p); err != nil {
+		return Pack{}, err
+	}
+	if err := checkRetired(p); err != nil {
+		return Pack{}, err
+	}
+	return p, nil
+}
+
+// IsBinaryPack reports whether data starts with the binary pack magic.
+func IsBinaryPack(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(packMagic))
+}
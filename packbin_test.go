@@ -0,0 +1,138 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"slices"
+	"testing"
+)
+
+// TestPackBinary_RoundTrip checks that sorted and append-only packs survive
+// MarshalBinary/ParsePackBinary with metadata and exact ordinals, so codes
+// encoded against the original decode against the converted pack.
+func TestPackBinary_RoundTrip(t *testing.T) {
+	sorted, err := BuildPack([]uint64{9, 1 << 40, 3, 700, 701, 1<<64 - 1}, PackBuildOpts{FormatID: 5, Name: "Std", Deduplicate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted.CreatedAt = "2025-09-01T00:00:00Z"
+	sorted.SchemaVersion = 2
+	ao := appendOnlyPack(t)
+
+	for _, p := range []Pack{sorted, ao} {
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("fid %d: MarshalBinary: %v", p.FormatID, err)
+		}
+		if !IsBinaryPack(b) {
+			t.Fatalf("fid %d: missing magic", p.FormatID)
+		}
+		got, err := ParsePackBinary(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("fid %d: ParsePackBinary: %v", p.FormatID, err)
+		}
+		if got.FormatID != p.FormatID || got.Name != p.Name || got.CreatedAt != p.CreatedAt ||
+			got.SchemaVersion != p.SchemaVersion || got.AppendOnly != p.AppendOnly || !slices.Equal(got.Cards, p.Cards) {
+			t.Fatalf("fid %d: round trip mismatch:\n got %+v\nwant %+v", p.FormatID, got, p)
+		}
+
+		in := DeckInput{Leader: []uint64{p.Cards[1]}, Deck: map[uint64]uint8{p.Cards[0]: 2, p.Cards[3]: 4}}
+		code, err := Encode(p, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := Decode(got, code)
+		if err != nil || !Equal(in, out.ToInput()) {
+			t.Fatalf("fid %d: decode with converted pack: %v %+v", p.FormatID, err, out)
+		}
+	}
+}
+
+// TestPackBinary_SmallerThanJSON checks the format's reason to exist.
+func TestPackBinary_SmallerThanJSON(t *testing.T) {
+	pks := make([]uint64, 2000)
+	for i := range pks {
+		pks[i] = 100000 + uint64(i)*37
+	}
+	p, _ := BuildPack(pks, PackBuildOpts{FormatID: 1})
+	j, _ := json.Marshal(p)
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b)*3 > len(j) {
+		t.Fatalf("binary %d bytes, JSON %d bytes", len(b), len(j))
+	}
+}
+
+// TestPackBinary_UnknownSectionSkipped checks that readers skip metadata tags
+// they do not know.
+func TestPackBinary_UnknownSectionSkipped(t *testing.T) {
+	p := Pack{FormatID: 3, Name: "x", Cards: []uint64{1, 2, 3}}
+	b, _ := p.MarshalBinary()
+	// Insert tag 99 with a 2-byte payload before the name section
+	// (header: magic 4 + version 1 + flags 1 + fid 2 + M 1).
+	hdr := 9
+	ext := append(slices.Clone(b[:hdr]), 99, 2, 0xAA, 0xBB)
+	ext = append(ext, b[hdr:len(b)-4]...)
+	ext = reseal(ext)
+	got, err := ParsePackBinary(bytes.NewReader(ext))
+	if err != nil {
+		t.Fatalf("ParsePackBinary: %v", err)
+	}
+	if got.Name != "x" || !slices.Equal(got.Cards, p.Cards) {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+// TestPackBinary_Errors checks corrupt, truncated and invalid inputs.
+func TestPackBinary_Errors(t *testing.T) {
+	p := Pack{FormatID: 3, Name: "x", Cards: []uint64{10, 20, 300}}
+	b, _ := p.MarshalBinary()
+
+	flip := slices.Clone(b)
+	flip[len(flip)-6] ^= 1
+
+	version := slices.Clone(b[:len(b)-4])
+	version[4] = 2
+
+	trailing := append(slices.Clone(b[:len(b)-4]), 0)
+
+	zeroFID := slices.Clone(b[:len(b)-4])
+	zeroFID[6], zeroFID[7] = 0, 0
+
+	dup := Pack{FormatID: 4, AppendOnly: true, Cards: []uint64{5, 6}}
+	dupBin, _ := dup.MarshalBinary()
+	dupBin = slices.Clone(dupBin[:len(dupBin)-4])
+	dupBin[len(dupBin)-1] = 0 // second delta 0: repeats 5
+
+	cases := map[string][]byte{
+		"empty":     nil,
+		"json":      []byte(`{"format_id":1,"cards":[1]}`),
+		"checksum":  flip,
+		"truncated": reseal(slices.Clone(b[:len(b)-5])),
+		"version":   reseal(version),
+		"trailing":  reseal(trailing),
+		"zero fid":  reseal(zeroFID),
+		"duplicate": reseal(dupBin),
+	}
+	for name, data := range cases {
+		_, err := ParsePackBinary(bytes.NewReader(data))
+		var pe *PackError
+		if !errors.Is(err, ErrInvalidPack) || !errors.As(err, &pe) {
+			t.Errorf("%s: want *PackError matching ErrInvalidPack, got %v", name, err)
+		}
+	}
+
+	if _, err := (Pack{FormatID: 1, Cards: []uint64{2, 1}}).MarshalBinary(); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("unsorted pack: got %v", err)
+	}
+}
+
+// reseal appends a fresh checksum to a binary pack body.
+func reseal(body []byte) []byte {
+	return binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+}
@@ -0,0 +1,145 @@
+package deckcodec
+
+import (
+	"slices"
+	"strconv"
+)
+
+// ReprintClass declares the printings of one card: reprints of Canonical
+// under other PKs in later sets.
+//
+// Canonical must be in the pack's Cards. A printing that is not in Cards is
+// untracked: Encode accepts it as Canonical and Decode returns Canonical.
+// A printing that is also in Cards is tracked: it has its own ordinal and
+// Decode returns the printing the deck was built with. Either way,
+// CanonicalDeck and EqualModReprints treat all printings as the same card.
+type ReprintClass struct {
+	Canonical uint64   `json:"canonical"`
+	Printings []uint64 `json:"printings"`
+}
+
+// reprintIndex maps every printing in p.Reprints to its canonical PK, or
+// returns nil if p has none. It assumes checkReprints passed.
+func reprintIndex(p Pack) map[uint64]uint64 {
+	if len(p.Reprints) == 0 {
+		return nil
+	}
+	canon := make(map[uint64]uint64)
+	for _, rc := range p.Reprints {
+		for _, pk := range rc.Printings {
+			canon[pk] = rc.Canonical
+		}
+	}
+	return canon
+}
+
+// checkReprints validates p.Reprints, reporting the first of reprintIssues.
+func checkReprints(p Pack) error {
+	if len(p.Reprints) == 0 {
+		return nil
+	}
+	if issues := reprintIssues(p, cardPositions(p.Cards)); len(issues) > 0 {
+		return &PackError{FormatID: p.FormatID, Reason: "reprints: " + issues[0], Err: ErrInvalidPack}
+	}
+	return nil
+}
+
+// reprintIssues lists what is wrong with p.Reprints: every canonical PK must
+// be in cards (p.Cards by position, see cardPositions), and a PK belongs to at
+// most one class, either as its canonical card or as one of its printings.
+func reprintIssues(p Pack, cards map[uint64]int) []string {
+	seen := make(map[uint64]bool)
+	var issues []string
+	for _, rc := range p.Reprints {
+		if _, ok := cards[rc.Canonical]; !ok {
+			issues = append(issues, "canonical card "+strconv.FormatUint(rc.Canonical, 10)+" not in pack")
+		}
+		for _, pk := range append([]uint64{rc.Canonical}, rc.Printings...) {
+			if seen[pk] {
+				issues = append(issues, "pk "+strconv.FormatUint(pk, 10)+" in more than one class")
+			}
+			seen[pk] = true
+		}
+	}
+	return issues
+}
+
+// cardPositions maps each PK in cards to the position of its first
+// occurrence. Unlike an ordinal lookup it does not assume cards are sorted,
+// so it works on packs as read.
+func cardPositions(cards []uint64) map[uint64]int {
+	pos := make(map[uint64]int, len(cards))
+	for i, pk := range cards {
+		if _, dup := pos[pk]; !dup {
+			pos[pk] = i
+		}
+	}
+	return pos
+}
+
+// Canonical returns the canonical PK of pk under p's reprint classes, or pk
+// itself if it is not a printing.
+func (p Pack) Canonical(pk uint64) uint64 {
+	for _, rc := range p.Reprints {
+		if slices.Contains(rc.Printings, pk) {
+			return rc.Canonical
+		}
+	}
+	return pk
+}
+
+// CanonicalDeck returns in with every printing replaced by its canonical card
+// under p's reprint classes. Main deck counts of printings of the same card
+// are added up (and may exceed 4). Leader and tactics are sorted, as by Normalize.
+func CanonicalDeck(p Pack, in DeckInput) DeckInput {
+	canon := reprintIndex(p)
+	if canon == nil {
+		return Normalize(in)
+	}
+	of := func(pk uint64) uint64 {
+		if c, ok := canon[pk]; ok {
+			return c
+		}
+		return pk
+	}
+	out := DeckInput{
+		Leader:  make([]uint64, len(in.Leader)),
+		Tactics: make([]uint64, len(in.Tactics)),
+	}
+	for i, pk := range in.Leader {
+		out.Leader[i] = of(pk)
+	}
+	for i, pk := range in.Tactics {
+		out.Tactics[i] = of(pk)
+	}
+	if in.Deck != nil {
+		out.Deck = make(map[uint64]uint8, len(in.Deck))
+		for pk, c := range in.Deck {
+			out.Deck[of(pk)] += c
+		}
+	}
+	slices.Sort(out.Leader)
+	slices.Sort(out.Tactics)
+	return out
+}
+
+// EqualModReprints reports whether a and b are the same deck once printings
+// are replaced by their canonical cards under p's reprint classes.
+func EqualModReprints(p Pack, a, b DeckInput) bool {
+	if len(p.Reprints) == 0 {
+		return Equal(a, b)
+	}
+	return Equal(CanonicalDeck(p, a), CanonicalDeck(p, b))
+}
+
+// cloneReprints returns a deep copy of rs.
+func cloneReprints(rs []ReprintClass) []ReprintClass {
+	if rs == nil {
+		return nil
+	}
+	out := slices.Clone(rs)
+	for i := range out {
+		out[i].Printings = slices.Clone(out[i].Printings)
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// reprintPack has card 100 reprinted as 900 (untracked, not in Cards) and
+// 950 (tracked, in Cards).
+func reprintPack(t *testing.T) Pack {
+	t.Helper()
+	p, err := BuildPack([]uint64{100, 200, 300, 950}, PackBuildOpts{
+		FormatID: 40,
+		Reprints: []ReprintClass{{Canonical: 100, Printings: []uint64{900, 950}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestReprints_Encode(t *testing.T) {
+	p := reprintPack(t)
+	c, err := NewCodec(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canonical := DeckInput{Leader: []uint64{100}, Deck: map[uint64]uint8{100: 3, 200: 1}}
+	untracked := DeckInput{Leader: []uint64{900}, Deck: map[uint64]uint8{900: 3, 200: 1}}
+	for name, enc := range map[string]func(DeckInput) (string, error){
+		"Encode": func(in DeckInput) (string, error) { return Encode(p, in) },
+		"Codec":  c.Encode,
+	} {
+		want, err := enc(canonical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// An untracked printing encodes as its canonical card.
+		got, err := enc(untracked)
+		if err != nil || got != want {
+			t.Fatalf("%s: untracked printing: %q, %v; want %q", name, got, err, want)
+		}
+		// Counts of the canonical card and an untracked printing add up.
+		mixed, err := enc(DeckInput{Leader: []uint64{100}, Deck: map[uint64]uint8{100: 1, 900: 2, 200: 1}})
+		if err != nil || mixed != want {
+			t.Fatalf("%s: mixed printings: %q, %v; want %q", name, mixed, err, want)
+		}
+		var cre *CountRangeError
+		if _, err := enc(DeckInput{Deck: map[uint64]uint8{100: 2, 900: 3}}); !errors.As(err, &cre) || cre.PK != 100 || cre.Count != 5 {
+			t.Fatalf("%s: combined count over 4: %v", name, err)
+		}
+	}
+
+	// Decode returns the canonical card for untracked printings and the chosen
+	// printing for tracked ones.
+	code, _ := Encode(p, untracked)
+	out, err := Decode(p, code)
+	if err != nil || !Equal(out.ToInput(), canonical) {
+		t.Fatalf("decode untracked: %+v, %v", out, err)
+	}
+	tracked := DeckInput{Leader: []uint64{950}, Deck: map[uint64]uint8{950: 3, 200: 1}}
+	code, _ = Encode(p, tracked)
+	out, err = c.Decode(code)
+	if err != nil || !Equal(out.ToInput(), tracked) {
+		t.Fatalf("decode tracked: %+v, %v", out, err)
+	}
+	if !EqualModReprints(p, out.ToInput(), canonical) || Equal(out.ToInput(), canonical) {
+		t.Fatal("tracked printing should equal the canonical deck modulo reprints only")
+	}
+}
+
+func TestReprints_Helpers(t *testing.T) {
+	p := reprintPack(t)
+	if p.Canonical(900) != 100 || p.Canonical(950) != 100 || p.Canonical(200) != 200 {
+		t.Fatal("Canonical")
+	}
+	got := CanonicalDeck(p, DeckInput{Tactics: []uint64{950, 300}, Deck: map[uint64]uint8{900: 2, 950: 2, 100: 1}})
+	want := DeckInput{Tactics: []uint64{100, 300}, Deck: map[uint64]uint8{100: 5}}
+	if !slices.Equal(got.Tactics, want.Tactics) || !Equal(got, want) {
+		t.Fatalf("CanonicalDeck = %+v, want %+v", got, want)
+	}
+	if EqualModReprints(p, DeckInput{Leader: []uint64{900}}, DeckInput{Leader: []uint64{200}}) {
+		t.Fatal("different cards compared equal")
+	}
+}
+
+func TestReprints_Invalid(t *testing.T) {
+	for name, rs := range map[string][]ReprintClass{
+		"canonical missing": {{Canonical: 7, Printings: []uint64{8}}},
+		"two classes":       {{Canonical: 100, Printings: []uint64{900}}, {Canonical: 200, Printings: []uint64{900}}},
+		"canonical reprint": {{Canonical: 100, Printings: []uint64{200}}, {Canonical: 200}},
+	} {
+		_, err := BuildPack([]uint64{100, 200}, PackBuildOpts{FormatID: 1, Reprints: rs})
+		if !errors.Is(err, ErrInvalidPack) {
+			t.Errorf("%s: BuildPack: %v", name, err)
+		}
+		p := Pack{FormatID: 1, Cards: []uint64{100, 200}, Reprints: rs}
+		if _, err := NewCodec(p); !errors.Is(err, ErrInvalidPack) {
+			t.Errorf("%s: NewCodec: %v", name, err)
+		}
+		js, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParsePack(bytes.NewReader(js)); !errors.Is(err, ErrInvalidPack) {
+			t.Errorf("%s: ParsePack: %v", name, err)
+		}
+		if probs := ValidatePack(p); len(probs) == 0 || probs[len(probs)-1].Kind != ProblemReprint {
+			t.Errorf("%s: ValidatePack: %v", name, probs)
+		}
+	}
+}
+
+// TestReprints_Serialization checks that reprint classes survive JSON, binary
+// and Codec.Pack.
+func TestReprints_Serialization(t *testing.T) {
+	p := reprintPack(t)
+	b, _ := json.Marshal(p)
+	fromJSON, err := ParsePack(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromBin, err := ParsePackBinary(bytes.NewReader(bin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := NewCodec(p)
+	for name, got := range map[string]Pack{"json": fromJSON, "binary": fromBin, "codec": c.Pack()} {
+		if len(got.Reprints) != 1 || got.Reprints[0].Canonical != 100 || !slices.Equal(got.Reprints[0].Printings, []uint64{900, 950}) {
+			t.Errorf("%s: reprints %+v", name, got.Reprints)
+		}
+	}
+}
+
+// TestReprints_ValidateUnsorted checks that ValidatePack finds canonical cards
+// in card lists that are not (yet) sorted.
+func TestReprints_ValidateUnsorted(t *testing.T) {
+	rs := []ReprintClass{{Canonical: 10, Printings: []uint64{99}}}
+	for _, appendOnly := range []bool{false, true} {
+		p := Pack{FormatID: 1, AppendOnly: appendOnly, Cards: []uint64{50, 10, 20, 30, 40}, Reprints: rs}
+		for _, pr := range ValidatePack(p) {
+			if pr.Kind == ProblemReprint {
+				t.Errorf("append-only %v: %v", appendOnly, pr)
+			}
+		}
+	}
+}
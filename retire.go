@@ -0,0 +1,70 @@
+package deckcodec
+
+import (
+	"slices"
+	"strconv"
+)
+
+// retiredIndex returns the set of p.Retired, or nil if p has none. Codec uses
+// it; the free functions scan p.Retired, which is short.
+func retiredIndex(p Pack) map[uint64]bool {
+	if len(p.Retired) == 0 {
+		return nil
+	}
+	set := make(map[uint64]bool, len(p.Retired))
+	for _, pk := range p.Retired {
+		set[pk] = true
+	}
+	return set
+}
+
+// checkRetired validates p.Retired, reporting the first of retiredIssues.
+func checkRetired(p Pack) error {
+	if len(p.Retired) == 0 {
+		return nil
+	}
+	if issues := retiredIssues(p, cardPositions(p.Cards)); len(issues) > 0 {
+		return &PackError{FormatID: p.FormatID, Reason: "retired: " + issues[0], Err: ErrInvalidPack}
+	}
+	return nil
+}
+
+// retiredIssues lists retired PKs that are not in cards (p.Cards by
+// position, see cardPositions): a tombstone keeps its card's ordinal, so the
+// card must stay in the pack.
+func retiredIssues(p Pack, cards map[uint64]int) []string {
+	var issues []string
+	for _, pk := range p.Retired {
+		if _, ok := cards[pk]; !ok {
+			issues = append(issues, "card "+strconv.FormatUint(pk, 10)+" not in pack")
+		}
+	}
+	return issues
+}
+
+// IsRetired reports whether Encode rejects pk as retired in p. An untracked
+// reprint (see ReprintClass) is retired with its canonical card.
+func (p Pack) IsRetired(pk uint64) bool {
+	if slices.Contains(p.Retired, pk) {
+		return true
+	}
+	c := p.Canonical(pk)
+	return c != pk && slices.Contains(p.Retired, c) && !slices.Contains(p.Cards, pk)
+}
+
+// RetireCards returns a copy of p with pks added to Retired. Cards and
+// ordinals are unchanged, so every existing code still decodes.
+func RetireCards(p Pack, pks ...uint64) (Pack, error) {
+	out := p
+	out.Retired = slices.Clone(p.Retired)
+	for _, pk := range pks {
+		if !slices.Contains(out.Retired, pk) {
+			out.Retired = append(out.Retired, pk)
+		}
+	}
+	slices.Sort(out.Retired)
+	if err := checkRetired(out); err != nil {
+		return Pack{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package deckcodec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRetired_EncodeDecode(t *testing.T) {
+	p, err := BuildPack([]uint64{100, 200, 300, 400}, PackBuildOpts{FormatID: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck := DeckInput{Leader: []uint64{300}, Tactics: []uint64{200}, Deck: map[uint64]uint8{100: 2, 300: 1, 400: 4}}
+	code, err := Encode(p, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retired, err := RetireCards(p, 300, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(retired.Retired, []uint64{100, 300}) || !slices.Equal(retired.Cards, p.Cards) {
+		t.Fatalf("RetireCards: %+v", retired)
+	}
+	c, err := NewCodec(retired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Existing codes keep decoding, with the retirement flagged.
+	for name, dec := range map[string]func(string) (DeckOutput, error){
+		"Decode": func(s string) (DeckOutput, error) { return Decode(retired, s) },
+		"Codec":  c.Decode,
+	} {
+		out, err := dec(code)
+		if err != nil || !Equal(out.ToInput(), deck) || !slices.Equal(out.Retired, []uint64{100, 300}) {
+			t.Fatalf("%s: %+v, %v", name, out, err)
+		}
+	}
+	o, _ := DecodeOrdinals(code, len(retired.Cards))
+	if out, err := o.Resolve(retired); err != nil || !slices.Equal(out.Retired, []uint64{100, 300}) {
+		t.Fatalf("Resolve: %+v, %v", out, err)
+	}
+	if out, _ := Decode(p, code); out.Retired != nil {
+		t.Fatalf("no retirements: Retired = %v", out.Retired)
+	}
+
+	// New codes cannot use retired cards unless allowed.
+	var rce *RetiredCardError
+	if _, err := Encode(retired, deck); !errors.As(err, &rce) || !errors.Is(err, ErrRetiredCard) || rce.Section != SectionLeader || rce.PK != 300 {
+		t.Fatalf("Encode: %v", err)
+	}
+	if _, err := c.Encode(DeckInput{Deck: map[uint64]uint8{100: 1}}); !errors.Is(err, ErrRetiredCard) {
+		t.Fatalf("Codec.Encode: %v", err)
+	}
+	for name, got := range map[string]func() (string, error){
+		"EncodeWithOpts": func() (string, error) { return EncodeWithOpts(retired, deck, EncodeOpts{AllowRetired: true}) },
+		"Codec":          func() (string, error) { return c.EncodeWithOpts(deck, EncodeOpts{AllowRetired: true}) },
+	} {
+		if s, err := got(); err != nil || s != code {
+			t.Fatalf("%s with AllowRetired: %q, %v; want %q", name, s, err, code)
+		}
+	}
+	if _, err := Encode(retired, DeckInput{Deck: map[uint64]uint8{200: 1, 400: 1}}); err != nil {
+		t.Fatalf("deck without retired cards: %v", err)
+	}
+}
+
+func TestRetired_Reprints(t *testing.T) {
+	p, err := BuildPack([]uint64{100, 200}, PackBuildOpts{
+		FormatID: 51,
+		Reprints: []ReprintClass{{Canonical: 100, Printings: []uint64{900}}},
+		Retired:  []uint64{100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsRetired(100) || !p.IsRetired(900) || p.IsRetired(200) || p.IsRetired(7) {
+		t.Fatal("IsRetired")
+	}
+	var rce *RetiredCardError
+	if _, err := Encode(p, DeckInput{Deck: map[uint64]uint8{900: 1}}); !errors.As(err, &rce) || rce.PK != 900 {
+		t.Fatalf("untracked printing of a retired card: %v", err)
+	}
+}
+
+func TestRetired_Invalid(t *testing.T) {
+	if _, err := BuildPack([]uint64{1, 2}, PackBuildOpts{FormatID: 1, Retired: []uint64{3}}); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("BuildPack: %v", err)
+	}
+	p := Pack{FormatID: 1, Cards: []uint64{1, 2}, Retired: []uint64{3}}
+	if _, err := RetireCards(Pack{FormatID: 1, Cards: []uint64{1, 2}}, 3); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("RetireCards: %v", err)
+	}
+	if _, err := NewCodec(p); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("NewCodec: %v", err)
+	}
+	js, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePack(bytes.NewReader(js)); !errors.Is(err, ErrInvalidPack) {
+		t.Fatalf("ParsePack: %v", err)
+	}
+	if probs := ValidatePack(p); len(probs) != 1 || probs[0].Kind != ProblemRetired {
+		t.Fatalf("ValidatePack: %v", probs)
+	}
+}
+
+// TestRetired_Serialization checks that Retired survives JSON, binary and Codec.Pack.
+func TestRetired_Serialization(t *testing.T) {
+	p := Pack{FormatID: 2, Cards: []uint64{1, 2, 1 << 40}, Retired: []uint64{2, 1 << 40}}
+	b, _ := json.Marshal(p)
+	fromJSON, err := ParsePack(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, _ := p.MarshalBinary()
+	fromBin, err := ParsePackBinary(bytes.NewReader(bin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := NewCodec(p)
+	for name, got := range map[string]Pack{"json": fromJSON, "binary": fromBin, "codec": c.Pack()} {
+		if !slices.Equal(got.Retired, p.Retired) {
+			t.Errorf("%s: retired %v", name, got.Retired)
+		}
+	}
+}
+
+// TestRetired_ValidateUnsorted checks that ValidatePack finds retired cards
+// in card lists that are not (yet) sorted.
+func TestRetired_ValidateUnsorted(t *testing.T) {
+	for _, appendOnly := range []bool{false, true} {
+		p := Pack{FormatID: 1, AppendOnly: appendOnly, Cards: []uint64{50, 10, 20, 30, 40}, Retired: []uint64{10}}
+		for _, pr := range ValidatePack(p) {
+			if pr.Kind == ProblemRetired {
+				t.Errorf("append-only %v: %v", appendOnly, pr)
+			}
+		}
+	}
+}
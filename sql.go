@@ -0,0 +1,130 @@
+package deckcodec
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrNoPack is returned by a PackResolver that has no pack for a format ID.
+var ErrNoPack = errors.New("deckcodec: no pack for format_id")
+
+// PackResolver looks up the pack for a format ID, e.g. from a cache filled
+// from the manifest.
+type PackResolver interface {
+	Pack(formatID uint16) (Pack, error)
+}
+
+// PackResolverFunc adapts a function to PackResolver.
+type PackResolverFunc func(formatID uint16) (Pack, error)
+
+func (f PackResolverFunc) Pack(formatID uint16) (Pack, error) { return f(formatID) }
+
+// PackMap is a PackResolver over a fixed set of packs keyed by format ID.
+type PackMap map[uint16]Pack
+
+func (m PackMap) Pack(formatID uint16) (Pack, error) {
+	p, ok := m[formatID]
+	if !ok {
+		return Pack{}, fmt.Errorf("%w %d", ErrNoPack, formatID)
+	}
+	return p, nil
+}
+
+// NullDeck stores a deck in a database column as its code. It implements
+// driver.Valuer and sql.Scanner: saving encodes Deck with the pack for
+// Deck.FormatID, loading decodes the code with the pack for its format ID.
+// Both use Resolver, which must be set before Scan. A NULL column maps to
+// Valid == false, like sql.NullString. Saving accepts the retired cards that
+// Scan reported in Deck.Retired, so a loaded deck can always be saved back;
+// any other retired card fails with ErrRetiredCard, as with Encode.
+//
+//	d := deckcodec.NullDeck{Resolver: packs}
+//	err := db.QueryRow("SELECT deck FROM decks WHERE id = $1", id).Scan(&d)
+type NullDeck struct {
+	Deck     DeckOutput
+	Valid    bool // Valid is true if Deck is not NULL
+	Resolver PackResolver
+}
+
+// errNoResolver is returned when a NullDeck without Resolver is saved or loaded.
+var errNoResolver = errors.New("deckcodec: NullDeck.Resolver is nil")
+
+// Value implements driver.Valuer.
+func (n NullDeck) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if n.Resolver == nil {
+		return nil, errNoResolver
+	}
+	p, err := n.Resolver.Pack(n.Deck.FormatID)
+	if err != nil {
+		return nil, err
+	}
+	// A stored deck may hold cards retired since it was saved: saving it back
+	// must not fail, but no other retired card may be added.
+	in := DeckInput{Leader: n.Deck.Leader, Tactics: n.Deck.Tactics, Deck: n.Deck.Deck}
+	if err := checkRetiredIn(p, in, n.Deck.Retired); err != nil {
+		return nil, err
+	}
+	return EncodeWithOpts(p, in, EncodeOpts{AllowRetired: true})
+}
+
+// checkRetiredIn returns a *RetiredCardError for the first card of in that is
+// retired in p and not listed in allowed.
+func checkRetiredIn(p Pack, in DeckInput, allowed []uint64) error {
+	if len(p.Retired) == 0 {
+		return nil
+	}
+	check := func(sec Section, pks []uint64) error {
+		for _, pk := range pks {
+			if p.IsRetired(pk) && !slices.Contains(allowed, pk) {
+				return &RetiredCardError{PK: pk, Section: sec}
+			}
+		}
+		return nil
+	}
+	if err := check(SectionLeader, in.Leader); err != nil {
+		return err
+	}
+	if err := check(SectionTactics, in.Tactics); err != nil {
+		return err
+	}
+	return check(SectionDeck, slices.Sorted(maps.Keys(in.Deck)))
+}
+
+// Scan implements sql.Scanner for string, []byte and NULL columns.
+func (n *NullDeck) Scan(src any) error {
+	var code string
+	switch v := src.(type) {
+	case nil:
+		n.Deck, n.Valid = DeckOutput{}, false
+		return nil
+	case string:
+		code = v
+	case []byte:
+		code = string(v)
+	default:
+		return fmt.Errorf("deckcodec: cannot scan %T into NullDeck", src)
+	}
+	if n.Resolver == nil {
+		return errNoResolver
+	}
+	fid, err := PeekFormatID(code)
+	if err != nil {
+		return err
+	}
+	p, err := n.Resolver.Pack(fid)
+	if err != nil {
+		return err
+	}
+	out, err := Decode(p, code)
+	if err != nil {
+		return err
+	}
+	n.Deck, n.Valid = out, true
+	return nil
+}
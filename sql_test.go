@@ -0,0 +1,207 @@
+package deckcodec
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a minimal in-process database/sql driver: every DSN is a
+// one-column table. "INSERT" appends its argument, "SELECT" returns all rows.
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string][]driver.Value
+}
+
+var fake = &fakeDriver{tables: map[string][]driver.Value{}}
+
+func init() { sql.Register("deckcodec-fake", fake) }
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{d: d, table: name}, nil }
+
+type fakeConn struct {
+	d     *fakeDriver
+	table string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c: c, query: query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("fake: no transactions") }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 1
+	}
+	return 0
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	s.c.d.tables[s.c.table] = append(s.c.d.tables[s.c.table], args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	return &fakeRows{vals: append([]driver.Value(nil), s.c.d.tables[s.c.table]...)}, nil
+}
+
+type fakeRows struct{ vals []driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"deck"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	dest[0], r.vals = r.vals[0], r.vals[1:]
+	return nil
+}
+
+func TestNullDeck_RoundTrip(t *testing.T) {
+	db, err := sql.Open("deckcodec-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	packs := PackMap{11: testPack(11), 12: testPack(12)}
+	in := DeckInput{Leader: []uint64{101}, Tactics: []uint64{2117, 303}, Deck: map[uint64]uint8{1006: 4, 205: 1}}
+	decks := []NullDeck{
+		{Deck: DeckOutput{FormatID: 11, Leader: in.Leader, Tactics: in.Tactics, Deck: in.Deck}, Valid: true, Resolver: packs},
+		{Resolver: packs}, // NULL
+		{Deck: DeckOutput{FormatID: 12, Deck: map[uint64]uint8{412: 2}}, Valid: true, Resolver: packs},
+	}
+	for _, d := range decks {
+		if _, err := db.Exec("INSERT", d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The column holds plain codes.
+	want, _ := Encode(packs[11], in)
+	if got := fake.tables[t.Name()][0]; got != want {
+		t.Fatalf("stored %v, want %q", got, want)
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []NullDeck
+	for rows.Next() {
+		d := NullDeck{Resolver: packs}
+		if err := rows.Scan(&d); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, d)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d rows", len(got))
+	}
+	if !got[0].Valid || got[0].Deck.FormatID != 11 || !equalDeckCounts(got[0].Deck.Deck, in.Deck) {
+		t.Fatalf("row 0 = %+v", got[0])
+	}
+	if got[1].Valid {
+		t.Fatalf("row 1 = %+v, want NULL", got[1])
+	}
+	if !got[2].Valid || got[2].Deck.FormatID != 12 || got[2].Deck.Deck[412] != 2 {
+		t.Fatalf("row 2 = %+v", got[2])
+	}
+}
+
+func TestNullDeck_Errors(t *testing.T) {
+	packs := PackMap{11: testPack(11)}
+	d := NullDeck{Deck: DeckOutput{FormatID: 99}, Valid: true, Resolver: packs}
+	if _, err := d.Value(); !errors.Is(err, ErrNoPack) {
+		t.Fatalf("Value unknown format: %v", err)
+	}
+	d.Deck = DeckOutput{FormatID: 11, Deck: map[uint64]uint8{7: 1}}
+	if _, err := d.Value(); !errors.Is(err, ErrUnknownCard) {
+		t.Fatalf("Value unknown card: %v", err)
+	}
+	if _, err := (NullDeck{Valid: true}).Value(); err == nil {
+		t.Fatal("Value without resolver succeeded")
+	}
+
+	code, _ := Encode(testPack(12), DeckInput{})
+	var s NullDeck
+	if err := s.Scan(code); err == nil {
+		t.Fatal("Scan without resolver succeeded")
+	}
+	s.Resolver = packs
+	if err := s.Scan([]byte(code)); !errors.Is(err, ErrNoPack) {
+		t.Fatalf("Scan unknown format: %v", err)
+	}
+	if err := s.Scan(42); err == nil {
+		t.Fatal("Scan of int succeeded")
+	}
+	resolver := PackResolverFunc(func(fid uint16) (Pack, error) { return testPack(fid), nil })
+	s.Resolver = resolver
+	if err := s.Scan(code); err != nil || !s.Valid || s.Deck.FormatID != 12 {
+		t.Fatalf("Scan with PackResolverFunc: %+v, %v", s, err)
+	}
+	if err := s.Scan(nil); err != nil || s.Valid {
+		t.Fatalf("Scan(nil): %+v, %v", s, err)
+	}
+}
+
+// TestNullDeck_Retired loads a deck whose card was retired after it was saved
+// and saves it back unchanged.
+func TestNullDeck_Retired(t *testing.T) {
+	db, err := sql.Open("deckcodec-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	in := DeckInput{Leader: []uint64{101}, Deck: map[uint64]uint8{412: 2, 205: 1}}
+	old, _ := Encode(testPack(11), in)
+	if _, err := db.Exec("INSERT", old); err != nil {
+		t.Fatal(err)
+	}
+	retired, err := RetireCards(testPack(11), 412)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packs := PackMap{11: retired}
+
+	d := NullDeck{Resolver: packs}
+	if err := db.QueryRow("SELECT").Scan(&d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Deck.Retired) != 1 || d.Deck.Retired[0] != 412 {
+		t.Fatalf("Retired = %v, want [412]", d.Deck.Retired)
+	}
+	if _, err := db.Exec("INSERT", d); err != nil {
+		t.Fatalf("saving a deck with a retired card: %v", err)
+	}
+	if got := fake.tables[t.Name()][1]; got != old {
+		t.Fatalf("stored %v, want %q", got, old)
+	}
+
+	// Retired cards other than those Scan reported are still rejected.
+	d.Deck.Tactics = []uint64{412}
+	d.Deck.Retired = nil
+	fresh := NullDeck{Deck: DeckOutput{FormatID: 11, Leader: []uint64{412}}, Valid: true, Resolver: packs}
+	for _, nd := range []NullDeck{d, fresh} {
+		var rce *RetiredCardError
+		if _, err := nd.Value(); !errors.As(err, &rce) || !errors.Is(err, ErrRetiredCard) || rce.PK != 412 {
+			t.Fatalf("saving %+v: %v, want ErrRetiredCard", nd.Deck, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package deckcodec
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// MaxPackCards is the largest pack ValidatePack accepts. Larger packs still
+// encode, but every ordinal costs idBits(M) >= 21 bits and the pack JSON alone
+// runs to megabytes, which points to a broken export rather than a real format.
+const MaxPackCards = 1 << 20
+
+// maxPackSchemaVersion is the newest pack schema_version this package knows.
+const maxPackSchemaVersion = 1
+
+// PackProblemKind classifies a PackProblem.
+type PackProblemKind uint8
+
+const (
+	ProblemFormatID      PackProblemKind = iota + 1 // format_id is zero
+	ProblemEmpty                                    // no cards
+	ProblemTooLarge                                 // more than MaxPackCards cards
+	ProblemDuplicate                                // a PK appears more than once
+	ProblemUnsorted                                 // cards of a sorted pack are not ascending
+	ProblemCreatedAt                                // created_at is not RFC3339
+	ProblemSchemaVersion                            // unknown schema_version
+	ProblemReprint                                  // invalid reprint class
+	ProblemRetired                                  // retired card not in the pack
+)
+
+var problemKindNames = [...]string{
+	ProblemFormatID:      "format_id",
+	ProblemEmpty:         "empty",
+	ProblemTooLarge:      "too-large",
+	ProblemDuplicate:     "duplicate",
+	ProblemUnsorted:      "unsorted",
+	ProblemCreatedAt:     "created_at",
+	ProblemSchemaVersion: "schema_version",
+	ProblemReprint:       "reprint",
+	ProblemRetired:       "retired",
+}
+
+func (k PackProblemKind) String() string {
+	if int(k) < len(problemKindNames) && problemKindNames[k] != "" {
+		return problemKindNames[k]
+	}
+	return "PackProblemKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// PackProblem is one problem ValidatePack found. For card problems Index is
+// the position in Cards as given (before ParsePack sorts them) and PK the card;
+// for duplicates First is the position of the first occurrence. Index and
+// First are -1 when they do not apply.
+//
+// PackProblem is an error matching ErrInvalidPack.
+type PackProblem struct {
+	Kind   PackProblemKind
+	Index  int
+	First  int
+	PK     uint64
+	Detail string
+}
+
+func (p PackProblem) Error() string {
+	msg := "deckcodec: " + p.Kind.String()
+	if p.Index >= 0 {
+		msg += " at cards[" + strconv.Itoa(p.Index) + "]"
+	}
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	return msg
+}
+
+func (p PackProblem) Unwrap() error { return ErrInvalidPack }
+
+// ValidatePack returns every problem in p, or nil if there are none:
+// pack-level problems first, then card problems in card order, then reprint
+// and retirement problems. It checks for a zero format ID, an empty or
+// oversized card list, duplicate PKs (which make ordinals ambiguous), unsorted
+// cards in a sorted pack, a CreatedAt that is not RFC3339, an unknown
+// SchemaVersion, invalid Reprints and Retired cards missing from the pack.
+//
+// Validate packs as read, before anything sorts them: ParsePackWithOpts with
+// Strict does this.
+func ValidatePack(p Pack) []PackProblem {
+	var out []PackProblem
+	add := func(kind PackProblemKind, detail string) {
+		out = append(out, PackProblem{Kind: kind, Index: -1, First: -1, Detail: detail})
+	}
+	if p.FormatID == 0 {
+		add(ProblemFormatID, "must be non-zero")
+	}
+	if p.SchemaVersion < 0 || p.SchemaVersion > maxPackSchemaVersion {
+		add(ProblemSchemaVersion, "unknown version "+strconv.Itoa(p.SchemaVersion))
+	}
+	if p.CreatedAt != "" {
+		if _, err := time.Parse(time.RFC3339, p.CreatedAt); err != nil {
+			add(ProblemCreatedAt, strconv.Quote(p.CreatedAt)+" is not RFC3339")
+		}
+	}
+	switch {
+	case len(p.Cards) == 0:
+		add(ProblemEmpty, "no cards")
+	case len(p.Cards) > MaxPackCards:
+		add(ProblemTooLarge, strconv.Itoa(len(p.Cards))+" cards, limit "+strconv.Itoa(MaxPackCards))
+	}
+
+	first := make(map[uint64]int, len(p.Cards))
+	unsorted := false
+	for i, pk := range p.Cards {
+		if !p.AppendOnly && !unsorted && i > 0 && pk < p.Cards[i-1] {
+			// Report the first descent only; the rest follows from it.
+			unsorted = true
+			out = append(out, PackProblem{Kind: ProblemUnsorted, Index: i, First: -1, PK: pk,
+				Detail: "pk " + strconv.FormatUint(pk, 10) + " after " + strconv.FormatUint(p.Cards[i-1], 10)})
+		}
+		if j, ok := first[pk]; ok {
+			out = append(out, PackProblem{Kind: ProblemDuplicate, Index: i, First: j, PK: pk,
+				Detail: "pk " + strconv.FormatUint(pk, 10) + " first at cards[" + strconv.Itoa(j) + "]"})
+			continue
+		}
+		first[pk] = i
+	}
+	for _, issue := range reprintIssues(p, first) {
+		add(ProblemReprint, issue)
+	}
+	for _, issue := range retiredIssues(p, first) {
+		add(ProblemRetired, issue)
+	}
+	return out
+}
+
+// ParsePackOpts controls ParsePackWithOpts.
+type ParsePackOpts struct {
+	// Strict fails on any problem ValidatePack reports instead of sorting the
+	// cards and accepting the rest. The error is a *PackError whose Cause joins
+	// every PackProblem.
+	Strict bool
+}
+
+// ParsePackWithOpts is ParsePack with options. With the zero value it behaves
+// exactly like ParsePack.
+func ParsePackWithOpts(r io.Reader, opts ParsePackOpts) (Pack, error) {
+	p, err := readPackJSON(r)
+	if err != nil {
+		return Pack{}, err
+	}
+	if opts.Strict {
+		if problems := ValidatePack(p); len(problems) > 0 {
+			errs := make([]error, len(problems))
+			for i, pr := range problems {
+				errs[i] = pr
+			}
+			reason := "failed strict validation with " + strconv.Itoa(len(problems)) + " problem(s)"
+			return Pack{}, &PackError{FormatID: p.FormatID, Reason: reason, Err: ErrInvalidPack, Cause: errors.Join(errs...)}
+		}
+	}
+	return finishPack(p)
+}
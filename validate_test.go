@@ -0,0 +1,93 @@
+package deckcodec
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidatePack_OK(t *testing.T) {
+	p := Pack{FormatID: 1, CreatedAt: "2025-09-01T10:00:00Z", SchemaVersion: 1, Cards: []uint64{1, 5, 9}}
+	if problems := ValidatePack(p); problems != nil {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	// Append-only packs may be in any order.
+	if problems := ValidatePack(Pack{FormatID: 1, AppendOnly: true, Cards: []uint64{9, 1, 5}}); problems != nil {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
+
+// TestValidatePack_ReportsEverything checks that every problem is reported,
+// with card positions as given.
+func TestValidatePack_ReportsEverything(t *testing.T) {
+	p := Pack{FormatID: 0, CreatedAt: "yesterday", SchemaVersion: 7, Cards: []uint64{3, 5, 4, 5, 3}}
+	got := ValidatePack(p)
+	type key struct {
+		kind         PackProblemKind
+		index, first int
+		pk           uint64
+	}
+	var keys []key
+	for _, pr := range got {
+		keys = append(keys, key{pr.Kind, pr.Index, pr.First, pr.PK})
+	}
+	want := []key{
+		{ProblemFormatID, -1, -1, 0},
+		{ProblemSchemaVersion, -1, -1, 0},
+		{ProblemCreatedAt, -1, -1, 0},
+		{ProblemUnsorted, 2, -1, 4},
+		{ProblemDuplicate, 3, 1, 5},
+		{ProblemDuplicate, 4, 0, 3},
+	}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("problems:\n got %v\nwant %v", keys, want)
+	}
+	if s := got[4].Error(); s != "deckcodec: duplicate at cards[3]: pk 5 first at cards[1]" {
+		t.Fatalf("Error() = %q", s)
+	}
+	if !errors.Is(got[0], ErrInvalidPack) {
+		t.Fatal("PackProblem should match ErrInvalidPack")
+	}
+
+	if got := ValidatePack(Pack{FormatID: 1}); len(got) != 1 || got[0].Kind != ProblemEmpty {
+		t.Fatalf("empty pack: %v", got)
+	}
+	big := Pack{FormatID: 1, AppendOnly: true, Cards: make([]uint64, MaxPackCards+1)}
+	for i := range big.Cards {
+		big.Cards[i] = uint64(i)
+	}
+	if got := ValidatePack(big); len(got) != 1 || got[0].Kind != ProblemTooLarge {
+		t.Fatalf("oversized pack: %v", got)
+	}
+}
+
+// TestParsePackWithOpts_Strict checks that strict parsing fails where
+// ParsePack silently fixes or accepts the input.
+func TestParsePackWithOpts_Strict(t *testing.T) {
+	for name, src := range map[string]string{
+		"unsorted":   `{"format_id":1,"cards":[3,1,2]}`,
+		"duplicate":  `{"format_id":1,"cards":[1,2,2]}`,
+		"empty":      `{"format_id":1,"cards":[]}`,
+		"created_at": `{"format_id":1,"created_at":"2025-09-01","cards":[1]}`,
+		"schema":     `{"format_id":1,"schema_version":2,"cards":[1]}`,
+	} {
+		if _, err := ParsePack(bytes.NewBufferString(src)); err != nil {
+			t.Fatalf("%s: lenient ParsePack failed: %v", name, err)
+		}
+		if _, err := ParsePackWithOpts(bytes.NewBufferString(src), ParsePackOpts{}); err != nil {
+			t.Fatalf("%s: zero opts should match ParsePack: %v", name, err)
+		}
+		_, err := ParsePackWithOpts(bytes.NewBufferString(src), ParsePackOpts{Strict: true})
+		var pe *PackError
+		var pr PackProblem
+		if !errors.As(err, &pe) || !errors.Is(err, ErrInvalidPack) || !errors.As(err, &pr) {
+			t.Fatalf("%s: want *PackError with a PackProblem, got %v", name, err)
+		}
+	}
+
+	p, err := ParsePackWithOpts(bytes.NewBufferString(`{"format_id":1,"schema_version":1,"cards":[1,2,3]}`), ParsePackOpts{Strict: true})
+	if err != nil || !slices.Equal(p.Cards, []uint64{1, 2, 3}) {
+		t.Fatalf("valid pack: %+v, %v", p, err)
+	}
+}